@@ -4,23 +4,37 @@ import (
 	"net/http/fcgi"
 	"os"
 
+	"github.com/gotwarlost/crossies/internal/api"
+	"github.com/gotwarlost/crossies/internal/datafiles"
 	"github.com/gotwarlost/crossies/internal/server"
+	"github.com/gotwarlost/crossies/internal/session"
 	"github.com/spf13/cobra"
 )
 
 func main() {
+	var files datafiles.Files
+	var sessions session.Config
 	cmd := &cobra.Command{
 		Use:   "api.fcgi",
 		Short: "run a FastCGI version of the crossie API server",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
-			mux, err := server.CGIHandler()
+			if err := files.Load(); err != nil {
+				return err
+			}
+			store, err := sessions.Open()
+			if err != nil {
+				return err
+			}
+			mux, err := server.CGIHandler(api.Options{Sessions: store})
 			if err != nil {
 				return err
 			}
 			return fcgi.Serve(nil, mux)
 		},
 	}
+	files.AddFlags(cmd, false)
+	sessions.AddFlags(cmd)
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -6,19 +6,31 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/gotwarlost/crossies/internal/api"
+	"github.com/gotwarlost/crossies/internal/datafiles"
 	"github.com/gotwarlost/crossies/internal/server"
+	"github.com/gotwarlost/crossies/internal/session"
 	"github.com/spf13/cobra"
 )
 
 func main() {
 	var port int
 	var root string
+	var files datafiles.Files
+	var sessions session.Config
 	cmd := &cobra.Command{
 		Use:   "crossie-server",
 		Short: "run a fully contained crossie server for development use",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
-			mux, err := server.Handler(root)
+			if err := files.Load(); err != nil {
+				return err
+			}
+			store, err := sessions.Open()
+			if err != nil {
+				return err
+			}
+			mux, err := server.Handler(root, api.Options{Sessions: store})
 			if err != nil {
 				return err
 			}
@@ -29,6 +41,8 @@ func main() {
 	f := cmd.Flags()
 	f.IntVarP(&port, "port", "p", 8989, "port to run server on")
 	f.StringVar(&root, "root", server.DefaultRoot(), "root directory for static files")
+	files.AddFlags(cmd, false)
+	sessions.AddFlags(cmd)
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/abbreviations"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addAbbreviationsCommand(root *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "abbreviations word",
+		Aliases: []string{"abbr"},
+		Short:   "show the abbreviations that a word or phrase conventionally stands for in cryptic clues",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no word or phrase specified")
+			}
+			cmd.SilenceUsage = true
+			q := abbreviations.Query{Word: strings.Join(args, " ")}
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "find abbreviations")
+			}
+			for _, a := range result.Abbreviations {
+				fmt.Println(a)
+			}
+			return nil
+		},
+	}
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/analyse"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func printNode(n *analyse.Node, indent string) {
+	fmt.Printf("%s%s: %s\n", indent, n.Kind, n.Text)
+	for _, c := range n.Children {
+		printNode(c, indent+"  ")
+	}
+}
+
+func addAnalyseCommand(root *cobra.Command) {
+	var q analyse.Query
+	var limit int
+	cmd := &cobra.Command{
+		Use:     "analyse clue",
+		Aliases: []string{"analyze"},
+		Short:   "propose answers for a cryptic clue with explanations of the wordplay",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no clue specified")
+			}
+			cmd.SilenceUsage = true
+			q.Clue = strings.Join(args, " ")
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "analyse clue")
+			}
+			for _, w := range result.Warnings {
+				log.Println("warning:", w)
+			}
+			for i, c := range result.Candidates {
+				if limit > 0 && i == limit {
+					break
+				}
+				fmt.Printf("%s (%d)\n", c.Answer, c.Score)
+				for _, child := range c.Explanation.Children {
+					printNode(child, "  ")
+				}
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer if not at the end of the clue, e.g. 5")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	f.IntVarP(&q.TimeLimit, "time-limit", "t", 0, "seconds to wait for results (default 15)")
+	f.IntVarP(&limit, "limit", "l", 10, "maximum candidates to show, 0 for all")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/autofill"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addAutofillCommand(root *cobra.Command) {
+	var list string
+	var opts autofill.Options
+	cmd := &cobra.Command{
+		Use:   "autofill grid.txt",
+		Short: "fill the empty squares of a grid with words from a scored word list",
+		Long: `Fill the empty squares of a grid with words from a scored word list.
+
+The grid has one row per line with # for a block, . for an empty square and a letter for a
+square that is already filled. Word list lines have a word optionally followed by a semicolon
+or tab and a score, for example "gazebo;50".`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			words, err := autofillWords(list)
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+			grid, err := puzzle.ParseGrid(f)
+			if err != nil {
+				return errors.Wrapf(err, "read %s", args[0])
+			}
+			result, err := autofill.Fill(grid, words, opts)
+			if err != nil {
+				return errors.Wrap(err, "autofill")
+			}
+			fmt.Print(puzzle.FormatGrid(result.Grid))
+			fmt.Println()
+			for _, e := range result.Entries {
+				preset := ""
+				if e.Preset {
+					preset = " (preset)"
+				}
+				fmt.Printf("%s\t%s\t%d%s\n", e.Label, e.Word, e.Score, preset)
+			}
+			fmt.Printf("\nscore %d, %d steps in %v\n", result.Score, result.Nodes, result.Elapsed.Round(time.Millisecond))
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&list, "wordlist", "", "scored word list to fill from, defaults to the --dictionary list")
+	f.IntVar(&opts.MinScore, "min-score", 0, "minimum score of words to use")
+	f.DurationVar(&opts.TimeLimit, "time-limit", 30*time.Second, "time after which to give up")
+	root.AddCommand(cmd)
+}
+
+// autofillWords returns the word list from the supplied file, or the dictionary set with --dictionary.
+func autofillWords(file string) (*wordlist.List, error) {
+	if file != "" {
+		return wordlist.LoadFile(file)
+	}
+	if l, ok := wordlist.Default().(*wordlist.List); ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("no word list, use --wordlist or --dictionary")
+}
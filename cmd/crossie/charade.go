@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gotwarlost/crossies/internal/charade"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addCharadeCommand(root *cobra.Command) {
+	var q charade.Query
+	cmd := &cobra.Command{
+		Use:   "charade part...",
+		Short: "build answers from synonyms and abbreviations of each part in order, quote parts with spaces",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no parts specified")
+			}
+			cmd.SilenceUsage = true
+			q.Parts = args
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "build charades")
+			}
+			for _, e := range result.Entries {
+				fmt.Printf("%s\t%s\n", e.Answer, e.Derivation)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer, e.g. 3,4")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gotwarlost/crossies/internal/cluelist"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addParseCluesCommand(root *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   "parse-clues [file]",
+		Short: "parse a pasted clue list from a file or standard input into clues and enumerations",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			in := os.Stdin
+			if len(args) > 0 {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer func() { _ = f.Close() }()
+				in = f
+			}
+			text, err := io.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			q := cluelist.Query{Text: string(text)}
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "parse clues")
+			}
+			for _, w := range result.Warnings {
+				fmt.Fprintln(os.Stderr, "warning:", w)
+			}
+			for _, c := range result.Clues {
+				enum := ""
+				if c.Enumeration != nil {
+					enum = " " + c.Enumeration.String()
+				}
+				fmt.Printf("%s\t%s%s\n", c.Label, c.Text, enum)
+			}
+			return nil
+		},
+	}
+	root.AddCommand(cmd)
+}
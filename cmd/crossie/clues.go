@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/cluedb"
+	"github.com/gotwarlost/crossies/internal/datafiles"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func clueDB() (*cluedb.DB, error) {
+	db := cluedb.Default()
+	if db == nil {
+		return nil, fmt.Errorf("no clue database, use --clue-db")
+	}
+	return db, nil
+}
+
+func importClues(db *cluedb.DB, file string) (int, error) {
+	if _, err := puzzle.FormatForFile(file); err == nil {
+		p, err := puzzle.ReadFile(file)
+		if err != nil {
+			return 0, err
+		}
+		source := p.Title
+		if source == "" {
+			source = filepath.Base(file)
+		}
+		return db.ImportPuzzle(p, source), nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+	n, err := db.ImportTSV(f, filepath.Base(file))
+	if err != nil {
+		return n, errors.Wrapf(err, "import %s", file)
+	}
+	return n, nil
+}
+
+func addCluesCommand(root *cobra.Command, files *datafiles.Files) {
+	var q cluedb.Query
+	cmd := &cobra.Command{
+		Use:   "clues [words in clue]",
+		Short: "search the clue database for past clues",
+		Long: `Search the clue database for past clues by words in the clue, answer pattern or answer.
+
+Use --answer to see how else an answer has been clued. Words in the clue also match other forms
+of the same word, so "baking" finds clues with "baked".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if _, err := clueDB(); err != nil {
+				return err
+			}
+			q.Text = strings.Join(args, " ")
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "find clues")
+			}
+			for _, m := range result.Matches {
+				text := m.Clue
+				if m.Enumeration != "" {
+					text += " (" + m.Enumeration + ")"
+				}
+				fmt.Printf("%s\t%s\t%s\n", m.Answer, text, m.Source)
+			}
+			if result.Total > len(result.Matches) {
+				fmt.Printf("... %d more\n", result.Total-len(result.Matches))
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&q.Answer, "answer", "a", "", "show how else this answer has been clued")
+	f.StringVar(&q.Frame, "frame", "", "known letters of the answer with dots for unknown ones")
+	f.StringVarP(&q.Enumeration, "enumeration", "e", "", "lengths of the answer words")
+	f.IntVarP(&q.Limit, "limit", "n", 50, "maximum number of clues to show")
+
+	imp := &cobra.Command{
+		Use:   "import file...",
+		Short: "add clues from puzzle files or tab separated files to the clue database",
+		Long: `Add clues from puzzle files or tab separated files to the clue database, creating it if needed.
+
+Puzzle files (.puz, .ipuz and .jpz) contribute the clues whose answers are in the grid. Other files
+have one clue per line with the clue, a tab, the answer and optionally a tab and the source.`,
+		Args: cobra.MinimumNArgs(1),
+		// replaces the loading of data files by the root command, as only importing creates the database
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			files.CreateClueDB = true
+			return files.Load()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			db, err := clueDB()
+			if err != nil {
+				return err
+			}
+			// saved after each file so that the clues of earlier files are kept when a later one fails
+			for _, file := range args {
+				n, err := importClues(db, file)
+				if err != nil {
+					return err
+				}
+				if err := db.Save(); err != nil {
+					return err
+				}
+				fmt.Printf("%s: %d clues added\n", file, n)
+			}
+			fmt.Printf("%d clues in database\n", db.Len())
+			return nil
+		},
+	}
+	cmd.AddCommand(imp)
+	root.AddCommand(cmd)
+}
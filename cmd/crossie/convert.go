@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addConvertCommand(root *cobra.Command) {
+	var key int
+	cmd := &cobra.Command{
+		Use:   "convert in.(puz|ipuz|jpz) out.(puz|ipuz|jpz)",
+		Short: "convert a puzzle between formats, based on the file extensions",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			p, err := puzzle.ReadFile(args[0])
+			if err != nil {
+				return errors.Wrapf(err, "read %s", args[0])
+			}
+			if p.Scrambled() {
+				if key != 0 {
+					if err := p.Unscramble(key); err != nil {
+						return err
+					}
+				} else if format, _ := puzzle.FormatForFile(args[1]); format != puzzle.FormatPuz {
+					return errors.New("solution is scrambled, supply --key to unscramble it")
+				}
+			}
+			return p.WriteFile(args[1])
+		},
+	}
+	cmd.Flags().IntVar(&key, "key", 0, "four digit key to unscramble a locked .puz solution")
+	root.AddCommand(cmd)
+}
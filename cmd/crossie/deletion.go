@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/deletion"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addDeletionCommand(root *cobra.Command) {
+	var q deletion.Query
+	var deletionType string
+	cmd := &cobra.Command{
+		Use:     "deletion word",
+		Aliases: []string{"del"},
+		Short:   "find words formed by removing letters from synonyms of the supplied word",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no word or phrase specified")
+			}
+			cmd.SilenceUsage = true
+			q.Word = strings.Join(args, " ")
+			q.Type = deletion.Type(deletionType)
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "find deletions")
+			}
+			for _, e := range result.Entries {
+				fmt.Printf("%s\t%s - %s\n", e.Answer, e.Synonym, strings.ToUpper(e.Removed))
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&deletionType, "type", "t", string(deletion.TypeFirst), "deletion type: first, last, middle, ends or letters")
+	f.StringVarP(&q.Letters, "letters", "l", "", "letters to remove for the letters deletion type")
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer, e.g. 5")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/doubledef"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addDoubleDefinitionCommand(root *cobra.Command) {
+	var q doubledef.Query
+	cmd := &cobra.Command{
+		Use:     "double-definition clue",
+		Aliases: []string{"dd"},
+		Short:   "solve a clue made of two definitions by intersecting synonyms of every split of the clue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no clue specified")
+			}
+			cmd.SilenceUsage = true
+			q.Clue = strings.Join(args, " ")
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "solve double definition")
+			}
+			for _, e := range result.Entries {
+				fmt.Printf("%s\t%s / %s\n", e.Answer, e.Left, e.Right)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&q.Enumeration, "len", "n", "", "enumeration of the answer if not at the end of the clue, e.g. 5 or 3,4")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	root.AddCommand(cmd)
+}
@@ -18,20 +18,15 @@ func addFindWordsCommand(root *cobra.Command) {
 				return fmt.Errorf("exactly one word must be specified")
 			}
 			cmd.SilenceUsage = true
-			next := 1
-			for {
-				q := findwords.Query{Frame: args[0], Page: next}
-				result, err := q.Run()
-				if err != nil {
-					return errors.Wrap(err, "find words")
-				}
+			q := findwords.Query{Frame: args[0]}
+			err := q.Stream(0, func(result *findwords.Result, _ findwords.Progress) error {
 				for _, w := range result.Words {
 					fmt.Println(w)
 				}
-				next = result.NextPage
-				if next == 0 {
-					break
-				}
+				return nil
+			})
+			if err != nil {
+				return errors.Wrap(err, "find words")
 			}
 			return nil
 		},
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/fodder"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addFodderCommand(root *cobra.Command) {
+	var q fodder.Query
+	cmd := &cobra.Command{
+		Use:   "fodder clue",
+		Short: "find runs of clue words that could be anagram fodder for the answer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no clue specified")
+			}
+			cmd.SilenceUsage = true
+			q.Clue = strings.Join(args, " ")
+
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "find fodder")
+			}
+			for _, c := range result.Candidates {
+				line := c.Fodder
+				if c.Indicator != "" {
+					line += fmt.Sprintf(" [%s]", c.Indicator)
+				}
+				if len(c.Anagrams) > 0 {
+					line += ": " + strings.Join(c.Anagrams, ", ")
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer if not at the end of the clue, e.g. 3,4")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	f.BoolVarP(&q.Solve, "solve", "s", false, "find anagrams of the best candidates")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/gridcheck"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// readGrid reads a puzzle file, or a text grid when the file does not have a puzzle extension.
+func readGrid(file string) (*puzzle.Puzzle, error) {
+	if _, err := puzzle.FormatForFile(file); err == nil {
+		return puzzle.ReadFile(file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	p, err := puzzle.ParseGrid(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", file)
+	}
+	return p, nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func printGridReport(r *gridcheck.Report) {
+	s := r.Symmetry
+	fmt.Printf("size:          %dx%d\n", r.Width, r.Height)
+	fmt.Printf("symmetry:      rotational %s, quarter turn %s, left-right %s, top-bottom %s, diagonal %s, anti-diagonal %s\n",
+		yesNo(s.Rotational), yesNo(s.QuarterTurn), yesNo(s.LeftRight), yesNo(s.TopBottom), yesNo(s.Diagonal), yesNo(s.AntiDiagonal))
+	fmt.Printf("connected:     %s\n", yesNo(r.Connected))
+	fmt.Printf("words:         %d\n", r.Words)
+	fmt.Printf("blocks:        %d of %d squares\n", r.Blocks, r.Width*r.Height)
+	fmt.Printf("average:       %.2f letters\n", r.AverageLength)
+	var lengths []int
+	for n := range r.Lengths {
+		lengths = append(lengths, n)
+	}
+	sort.Ints(lengths)
+	var parts []string
+	for _, n := range lengths {
+		parts = append(parts, fmt.Sprintf("%d:%d", n, r.Lengths[n]))
+	}
+	fmt.Printf("lengths:       %s\n", strings.Join(parts, " "))
+	if r.Letters.Filled > 0 {
+		parts = parts[:0]
+		for ch := 'A'; ch <= 'Z'; ch++ {
+			if n := r.Letters.Counts[string(ch)]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%c:%d", ch, n))
+			}
+		}
+		fmt.Printf("letters:       %s\n", strings.Join(parts, " "))
+		if r.Letters.Pangram {
+			fmt.Println("pangram:       yes")
+		} else {
+			fmt.Printf("missing:       %s\n", strings.Join(r.Letters.Missing, ""))
+		}
+	}
+	if len(r.Problems) > 0 {
+		fmt.Println()
+		for _, p := range r.Problems {
+			fmt.Printf("- %s\n", p)
+		}
+	}
+}
+
+func addGridCommand(root *cobra.Command) {
+	var minLength int
+	cmd := &cobra.Command{
+		Use:   "grid",
+		Short: "work with grids under construction",
+	}
+	check := &cobra.Command{
+		Use:   "check file",
+		Short: "check the symmetry, connectivity and fill statistics of a grid",
+		Long: `Check the symmetry, connectivity and fill statistics of a grid.
+
+The file is a .puz, .ipuz or .jpz puzzle, or a text grid with one row per line with # for a
+block, . for an empty square and a letter for a filled square.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			p, err := readGrid(args[0])
+			if err != nil {
+				return err
+			}
+			printGridReport(gridcheck.Check(p, minLength))
+			return nil
+		},
+	}
+	check.Flags().IntVar(&minLength, "min-length", 3, "minimum word length")
+	cmd.AddCommand(check)
+	root.AddCommand(cmd)
+}
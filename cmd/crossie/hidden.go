@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/hidden"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addHiddenCommand(root *cobra.Command) {
+	var q hidden.Query
+	cmd := &cobra.Command{
+		Use:   "hidden clue",
+		Short: "find words hidden in the clue text, forwards or reversed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no clue specified")
+			}
+			cmd.SilenceUsage = true
+			q.Clue = strings.Join(args, " ")
+
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "find hidden words")
+			}
+			for _, e := range result.Entries {
+				dir := ""
+				if e.Reversed {
+					dir = " (reversed)"
+				}
+				fmt.Printf("%s\t%s%s\n", e.Word, e.Span, dir)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer if not at the end of the clue, e.g. 5")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/homophones"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addHomophonesCommand(root *cobra.Command) {
+	var q homophones.Query
+	cmd := &cobra.Command{
+		Use:     "homophones word",
+		Aliases: []string{"homo"},
+		Short:   "find words that sound like the supplied word or phrase, requires --cmudict",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no word or phrase specified")
+			}
+			cmd.SilenceUsage = true
+			q.Word = strings.Join(args, " ")
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "find homophones")
+			}
+			for _, e := range result.Entries {
+				if e.Distance > 0 {
+					fmt.Printf("%s\t~%d\n", e.Word, e.Distance)
+				} else {
+					fmt.Println(e.Word)
+				}
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.IntVarP(&q.Tolerance, "tolerance", "t", 0, "maximum phoneme edits allowed for near-homophones")
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer, e.g. 5")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	f.IntVarP(&q.MinLetters, "min", "m", 0, "minimum letters that the homophone should have")
+	f.IntVarP(&q.MaxLetters, "max", "M", 0, "maximum letters that the homophone should have (0=any number)")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/indicators"
+	"github.com/spf13/cobra"
+)
+
+func addIndicatorsCommand(root *cobra.Command) {
+	var tagClue bool
+	cmd := &cobra.Command{
+		Use:     "indicators word-or-clue",
+		Aliases: []string{"ind"},
+		Short:   "show the cryptic indicator types for a word or phrase, or for every word of a clue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no word or clue specified")
+			}
+			cmd.SilenceUsage = true
+			text := strings.Join(args, " ")
+			if !tagClue {
+				for _, t := range indicators.Default().Lookup(text) {
+					fmt.Println(t)
+				}
+				return nil
+			}
+			for _, w := range indicators.Tag(text) {
+				var types []string
+				for _, t := range w.Types {
+					types = append(types, string(t))
+				}
+				fmt.Printf("%s\t%s\n", w.Text, strings.Join(types, ","))
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.BoolVarP(&tagClue, "clue", "c", false, "tag every word of the supplied clue")
+	root.AddCommand(cmd)
+}
@@ -4,19 +4,44 @@ import (
 	"log"
 	"os"
 
+	"github.com/gotwarlost/crossies/internal/datafiles"
 	"github.com/spf13/cobra"
 )
 
 const exe = "crossie"
 
 func setup() *cobra.Command {
+	var files datafiles.Files
 	root := &cobra.Command{
 		Use:   exe,
 		Short: "crossword tools",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return files.Load()
+		},
 	}
+	files.AddFlags(root, true)
 	addSynonymsCommand(root)
 	addFindWordsCommand(root)
 	addAnagramsCommand(root)
+	addFodderCommand(root)
+	addIndicatorsCommand(root)
+	addHiddenCommand(root)
+	addAbbreviationsCommand(root)
+	addCharadeCommand(root)
+	addHomophonesCommand(root)
+	addWordplayCommand(root)
+	addDeletionCommand(root)
+	addDoubleDefinitionCommand(root)
+	addSelectionCommand(root)
+	addAnalyseCommand(root)
+	addSpoonerismCommand(root)
+	addPuzCommand(root)
+	addConvertCommand(root)
+	addParseCluesCommand(root)
+	addAutofillCommand(root)
+	addGridCommand(root)
+	addRenderCommand(root)
+	addCluesCommand(root, &files)
 	return root
 }
 
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func readPuzFile(file string, key int) (*puzzle.Puzzle, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	p, err := puzzle.ReadPuz(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", file)
+	}
+	if key != 0 && p.Scrambled() {
+		if err := p.Unscramble(key); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func addPuzCommand(root *cobra.Command) {
+	var key int
+	cmd := &cobra.Command{
+		Use:   "puz",
+		Short: "work with Across Lite .puz files",
+	}
+	info := &cobra.Command{
+		Use:   "info file.puz",
+		Short: "show the details and clues of a puzzle",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			p, err := readPuzFile(args[0], key)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("title:     %s\n", p.Title)
+			fmt.Printf("author:    %s\n", p.Author)
+			fmt.Printf("copyright: %s\n", p.Copyright)
+			fmt.Printf("size:      %dx%d\n", p.Width, p.Height)
+			fmt.Printf("scrambled: %t\n", p.Scrambled())
+			if p.Notes != "" {
+				fmt.Printf("notes:     %s\n", p.Notes)
+			}
+			fmt.Println()
+			for _, s := range p.Slots() {
+				text := ""
+				if s.Clue != nil {
+					text = s.Clue.Text
+				}
+				fmt.Printf("%s\t%s (%d)\n", s.Label(), text, s.Length)
+			}
+			return nil
+		},
+	}
+	state := &cobra.Command{
+		Use:   "solve-state file.puz",
+		Short: "show every clue with the current frame of its slot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			p, err := readPuzFile(args[0], key)
+			if err != nil {
+				return err
+			}
+			for _, s := range p.Slots() {
+				text := ""
+				if s.Clue != nil {
+					text = s.Clue.Text
+				}
+				fmt.Printf("%s\t%s\t%s\n", s.Label(), p.Frame(s), text)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().IntVarP(&key, "key", "k", 0, "four digit key to unscramble the solution")
+	cmd.AddCommand(info, state)
+	root.AddCommand(cmd)
+}
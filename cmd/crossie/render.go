@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/cluelist"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/render"
+	"github.com/spf13/cobra"
+)
+
+// readPuzzle reads a puzzle file, a text grid, or a clue list, which gives a puzzle with clues and no grid.
+func readPuzzle(file string) (*puzzle.Puzzle, error) {
+	if _, err := puzzle.FormatForFile(file); err != nil {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		// grid lines never start with a number, so a text grid has no clues
+		if clues, _ := cluelist.Parse(string(text)); len(clues) > 0 {
+			return cluelist.Puzzle(clues), nil
+		}
+	}
+	return readGrid(file)
+}
+
+func addRenderCommand(root *cobra.Command) {
+	var opts render.Options
+	var paper string
+	cmd := &cobra.Command{
+		Use:   "render in out.svg|out.pdf",
+		Short: "draw a puzzle for printing as SVG or PDF",
+		Long: `Draw a puzzle for printing as SVG or PDF, chosen by the extension of the output file.
+
+The input is a .puz, .ipuz or .jpz puzzle, or a text grid with one row per line with # for a
+block, . for an empty square and a letter for a filled square. A text file with a pasted clue
+list, as accepted by parse-clues, is drawn as just the clues.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			p, err := readPuzzle(args[0])
+			if err != nil {
+				return err
+			}
+			opts.Paper = render.Paper(paper)
+			var b bytes.Buffer
+			switch ext := strings.ToLower(filepath.Ext(args[1])); ext {
+			case ".svg":
+				err = render.WriteSVG(&b, p, opts)
+			case ".pdf":
+				err = render.WritePDF(&b, p, opts)
+			default:
+				return fmt.Errorf("unknown output format %q, expected .svg or .pdf", ext)
+			}
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(args[1], b.Bytes(), 0644)
+		},
+	}
+	f := cmd.Flags()
+	f.BoolVar(&opts.Solution, "solution", false, "show the solution in the grid")
+	f.BoolVar(&opts.LargePrint, "large-print", false, "use bigger squares, text and lines")
+	f.BoolVar(&opts.GridOnly, "grid-only", false, "leave out the title and clues")
+	f.StringVar(&paper, "paper", string(render.PaperA4), "paper size, a4 or letter")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/selection"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addSelectionCommand(root *cobra.Command) {
+	var q selection.Query
+	var mode, scope string
+	cmd := &cobra.Command{
+		Use:     "select clue",
+		Aliases: []string{"acrostic"},
+		Short:   "find words made of the first, last, odd, even or middle letters of runs of clue words",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no clue specified")
+			}
+			cmd.SilenceUsage = true
+			q.Clue = strings.Join(args, " ")
+			q.Mode = selection.Mode(mode)
+			q.Scope = selection.Scope(scope)
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "select letters")
+			}
+			for _, e := range result.Entries {
+				fmt.Printf("%s\t%s\n", e.Answer, e.Span)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&mode, "mode", "m", string(selection.ModeFirst), "letters to select: first, last, odd, even or middle")
+	f.StringVarP(&scope, "scope", "s", "", "select from each word (words) or from the whole run (run), defaults by mode")
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer if not at the end of the clue, e.g. 5")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	root.AddCommand(cmd)
+}
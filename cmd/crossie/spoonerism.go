@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/spoonerism"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addSpoonerismCommand(root *cobra.Command) {
+	var q spoonerism.Query
+	cmd := &cobra.Command{
+		Use:     "spoonerism [phrase]",
+		Aliases: []string{"spoon"},
+		Short:   "swap the initial sounds of words in a phrase, or find phrases by enumeration whose spoonerism is real",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && q.Enumeration == "" {
+				return fmt.Errorf("no phrase or enumeration specified")
+			}
+			cmd.SilenceUsage = true
+			q.Phrase = strings.Join(args, " ")
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "find spoonerisms")
+			}
+			for _, e := range result.Entries {
+				fmt.Printf("%s\t%s\n", e.Phrase, e.Spoonerism)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of phrases to find, e.g. 4,5, requires --dictionary")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the phrase to find with . for unknown letters")
+	f.IntVarP(&q.Limit, "limit", "l", 0, "maximum phrases to find (default 100)")
+	root.AddCommand(cmd)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gotwarlost/crossies/internal/wordplay"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func addWordplayCommand(root *cobra.Command) {
+	var q wordplay.Query
+	cmd := &cobra.Command{
+		Use:   "wordplay [left-fragment] [right-fragment]",
+		Short: "solve containers and reversals using synonyms of one or two clue fragments, quote fragments with spaces",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 2 {
+				return fmt.Errorf("at most two fragments may be specified")
+			}
+			cmd.SilenceUsage = true
+			if len(args) > 0 {
+				q.Left = args[0]
+			}
+			if len(args) > 1 {
+				q.Right = args[1]
+			}
+			result, err := q.Run()
+			if err != nil {
+				return errors.Wrap(err, "solve wordplay")
+			}
+			for _, c := range result.Constructions {
+				fmt.Printf("%s\t%s\n", c.Answer, c.Derivation)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringSliceVarP(&q.LeftWords, "left", "l", nil, "additional components for the left fragment")
+	f.StringSliceVarP(&q.RightWords, "right", "r", nil, "additional components for the right fragment")
+	f.StringVarP(&q.Enumeration, "enum", "n", "", "enumeration of the answer, e.g. 7")
+	f.StringVarP(&q.Frame, "frame", "f", "", "known letters of the answer with . for unknown letters")
+	root.AddCommand(cmd)
+}
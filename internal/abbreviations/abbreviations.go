@@ -0,0 +1,151 @@
+package abbreviations
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+// Lexicon maps words and phrases to the abbreviations they conventionally stand for in cryptic clues.
+type Lexicon struct {
+	l       sync.RWMutex
+	entries map[string]map[string]bool
+}
+
+// New returns an empty lexicon.
+func New() *Lexicon {
+	return &Lexicon{entries: map[string]map[string]bool{}}
+}
+
+var defaultLexicon = func() *Lexicon {
+	l := New()
+	if err := l.Load(strings.NewReader(builtin)); err != nil {
+		panic(errors.Wrap(err, "load builtin abbreviations"))
+	}
+	return l
+}()
+
+// Default returns the lexicon containing the built-in abbreviations and any user additions.
+func Default() *Lexicon {
+	return defaultLexicon
+}
+
+// LoadFile adds the abbreviations in the supplied file to the default lexicon.
+func LoadFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return errors.Wrapf(defaultLexicon.Load(f), "load %s", file)
+}
+
+func normalize(phrase string) string {
+	var parts []string
+	for _, w := range clue.Split(phrase) {
+		parts = append(parts, w.Letters)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Add adds abbreviations for the supplied word or phrase.
+func (l *Lexicon) Add(word string, abbrs ...string) {
+	key := normalize(word)
+	if key == "" {
+		return
+	}
+	l.l.Lock()
+	defer l.l.Unlock()
+	for _, a := range abbrs {
+		a = clue.Letters(a)
+		if a == "" {
+			continue
+		}
+		if l.entries[key] == nil {
+			l.entries[key] = map[string]bool{}
+		}
+		l.entries[key][a] = true
+	}
+}
+
+// Load adds abbreviations from the supplied reader. Each line consists of a word or phrase, an equals
+// sign and a comma-separated list of abbreviations, for example "sailor = ab, tar". Blank lines and
+// lines starting with # are ignored.
+func (l *Lexicon) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pos := strings.Index(line, "=")
+		if pos < 0 {
+			return inputerror.New(fmt.Sprintf("line %d: no '=' found in %q", lineNo, line))
+		}
+		l.Add(line[:pos], strings.Split(line[pos+1:], ",")...)
+	}
+	return scanner.Err()
+}
+
+// Lookup returns the abbreviations for the supplied word or phrase in alphabetical order.
+func (l *Lexicon) Lookup(word string) []string {
+	l.l.RLock()
+	defer l.l.RUnlock()
+	var ret []string
+	for a := range l.entries[normalize(word)] {
+		ret = append(ret, a)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Query is a query for abbreviations.
+type Query struct {
+	Word string `json:"word"` // word or phrase to look up
+}
+
+func (q *Query) initialize() error {
+	if normalize(q.Word) == "" {
+		return inputerror.New("no word specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Word = values.Get("word")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Result is the result of an abbreviation query.
+type Result struct {
+	Query         *Query   `json:"query,omitempty"`
+	Abbreviations []string `json:"abbreviations"`
+}
+
+// Run looks up abbreviations for the query word in the default lexicon.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	abbrs := defaultLexicon.Lookup(q.Word)
+	if len(abbrs) == 0 {
+		return nil, errcode.NoResults("no abbreviations found for %q", q.Word)
+	}
+	return &Result{Query: q, Abbreviations: abbrs}, nil
+}
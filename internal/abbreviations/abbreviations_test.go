@@ -0,0 +1,53 @@
+package abbreviations_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/abbreviations"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexicon(t *testing.T) {
+	l := abbreviations.New()
+	require.NoError(t, l.Load(strings.NewReader(`# test abbreviations
+sailor = ab, tar, AB
+Head of state = hos
+
+old=o, ex,
+`)))
+	tests := []struct {
+		word  string
+		abbrs []string
+	}{
+		{"sailor", []string{"ab", "tar"}},
+		{"SAILOR!", []string{"ab", "tar"}},
+		{"head  of state", []string{"hos"}},
+		{"old", []string{"ex", "o"}},
+		{"new", nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.abbrs, l.Lookup(test.word), test.word)
+	}
+
+	err := abbreviations.New().Load(strings.NewReader("# bad\nsailor: ab\n"))
+	assert.Equal(t, errcode.InvalidInput, errcode.Of(err))
+	assert.EqualError(t, err, `line 2: no '=' found in "sailor: ab"`)
+}
+
+func TestQuery(t *testing.T) {
+	q := abbreviations.Query{Word: "Sailor"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.Contains(t, res.Abbreviations, "ab")
+
+	q = abbreviations.Query{Word: "xqzzy"}
+	_, err = q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	q = abbreviations.Query{Word: "!"}
+	_, err = q.Run()
+	assert.Equal(t, errcode.InvalidInput, errcode.Of(err))
+}
@@ -0,0 +1,246 @@
+package abbreviations
+
+// builtin is the default abbreviation lexicon. Each line has a word or phrase, an equals sign and a
+// comma-separated list of the abbreviations or components it conventionally stands for.
+const builtin = `
+# general
+about = c, ca, re
+account = ac
+afternoon = pm
+against = v, vs
+american = a, am
+ancient = a
+and = n
+answer = a, ans
+artist = ra
+article = a, an, the
+bachelor = b, ba
+bishop = b, rr
+black = b
+book = b, vol
+born = b, nee
+boy = lad
+bridge player = n, e, s, w
+british = b, br
+caught = c, ct
+century = c, ton
+church = ce, ch
+circle = o, ring
+city = ec, ny, la
+clergyman = rev
+college = c, eton
+conservative = c, con, tory
+daughter = d
+days = d
+degree = ba, ma
+died = d, ob
+doctor = dr, md, gp, mo, mb
+duck = o
+east = e
+editor = ed
+energy = e
+english = e, eng
+europe = e
+exercise = pe, pt
+fashionable = in
+fellow = f
+female = f
+firm = co
+following = f
+football = fa
+french = f, fr
+gallery = tate
+gangster = al, capone
+general = g, gen
+georgia = ga
+german = g, ger
+girl = gal, her
+good = g, pi
+graduate = ba, ma
+gramme = g
+hard = h
+he = h
+henry = h, hal
+home = in
+horse = gg, h, nag
+hospital = h
+hot = h
+hour = h, hr
+hundred = c, ton
+husband = h
+island = i, is
+italian = i, it
+journalist = ed, hack
+judge = j
+jack = j
+key = a, b, c, d, e, f, g
+king = k, r, rex, er
+knight = k, kt, n, sir
+lake = l
+large = l, os
+learner = l
+left = l, port
+liberal = l, lib
+line = l, ry
+little = wee
+love = o, nil
+male = m
+maiden = m
+married = m
+member = mp
+minute = m, min, mo
+model = t, sitter
+money = l, p
+monkey = ape
+mother = ma, mum
+motorway = mi, m
+new = n
+nothing = o, nil
+note = a, b, c, d, e, f, g, do, re, mi, fa, so, la, ti, te
+number = n, no
+old = o, ex
+one = a, an, i, ace
+opening = o
+page = p
+party = do, lab, con
+pence = p
+penny = p, d
+piano = p
+point = n, e, s, w, pt
+politician = mp
+power = p
+priest = eli, fr
+prime minister = pm
+quarter = n, e, s, w
+queen = q, r, er, hm
+question = q, qu
+railway = ry, rly
+record = ep, lp
+religious education = re
+resistance = r
+right = r, rt
+river = r, dee, exe, po, cam, ure
+road = rd, st
+round = o
+sailor = ab, tar, os, rating, salt
+second = s, mo, sec
+see = ely, lo
+sex appeal = it, sa
+ship = ss
+small = s
+society = s
+soldier = gi, or, para
+son = s
+south = s
+spades = s
+street = st
+student = l
+sun = s
+tax = vat
+territory = nt
+that is = ie
+the = t
+theatre = rep
+time = t
+ton = t
+united = u
+united states = us
+university = u, uni
+very = v
+victory = v, ve
+volume = v, vol
+vote = x
+way = rd, st
+west = w
+wife = w
+with = w
+women = w
+workers = ants, tuc
+yard = y
+year = y, yr
+yes = ay, aye
+# roman numerals
+one = i
+two = ii
+three = iii
+four = iv
+five = v
+six = vi
+seven = vii
+eight = viii
+nine = ix
+ten = x
+eleven = xi
+twenty = xx
+forty = xl
+fifty = l
+ninety = xc
+hundred = c
+five hundred = d
+thousand = m
+# chemical symbols
+hydrogen = h
+helium = he
+carbon = c
+nitrogen = n
+oxygen = o
+sodium = na
+magnesium = mg
+aluminium = al
+silicon = si
+phosphorus = p
+sulphur = s
+chlorine = cl
+potassium = k
+calcium = ca
+iron = fe
+nickel = ni
+copper = cu
+zinc = zn
+silver = ag
+tin = sn
+iodine = i
+tungsten = w
+platinum = pt
+gold = au, or
+mercury = hg
+lead = pb
+uranium = u
+# nato alphabet
+alpha = a
+bravo = b
+charlie = c
+delta = d
+echo = e
+foxtrot = f
+golf = g
+hotel = h
+india = i
+juliet = j
+kilo = k
+lima = l
+mike = m
+november = n
+oscar = o
+papa = p
+quebec = q
+romeo = r
+sierra = s
+tango = t
+uniform = u
+victor = v
+whiskey = w
+x-ray = x
+yankee = y
+zulu = z
+# french
+the french = le, la, les
+a french = un, une
+of french = de, du, des
+and french = et
+in french = en
+is french = est
+with french = avec
+french the = le, la, les
+french article = le, la, les, un, une
+`
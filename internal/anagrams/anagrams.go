@@ -1,12 +1,13 @@
 package anagrams
 
 import (
-	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
 
+	"github.com/gotwarlost/crossies/internal/errcode"
 	"github.com/gotwarlost/crossies/internal/htmlplus"
 	"github.com/gotwarlost/crossies/internal/inputerror"
 )
@@ -45,10 +46,37 @@ type Result struct {
 	Phrases []string `json:"phrases"` // words found in current iteration
 }
 
+// Solver finds the anagrams of the phrase of a query.
+type Solver func(query Query) (*Result, error)
+
+var (
+	defaultLock   sync.RWMutex
+	defaultSolver Solver = search
+)
+
+// Default returns the solver used by Solve, which searches thewordfinder unless another solver has been set.
+func Default() Solver {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultSolver
+}
+
+// SetDefault sets the solver used by Solve.
+func SetDefault(s Solver) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultSolver = s
+}
+
+// Solve finds the anagrams of the phrase of the query using the default solver.
 func Solve(query Query) (*Result, error) {
 	if err := query.initialize(); err != nil {
 		return nil, err
 	}
+	return Default()(query)
+}
+
+func search(query Query) (*Result, error) {
 	vals := url.Values{}
 	vals.Set("letters", query.Phrase)
 	vals.Set("extra", "")
@@ -79,7 +107,7 @@ func Solve(query Query) (*Result, error) {
 	}
 
 	if len(ret) == 0 {
-		return nil, inputerror.New(fmt.Sprintf("no anagrams found for %q", query.Phrase))
+		return nil, errcode.NoResults("no anagrams found for %q", query.Phrase)
 	}
 	sort.Slice(ret, func(i, j int) bool {
 		l1, l2 := len(ret[i]), len(ret[j])
@@ -0,0 +1,526 @@
+package analyse
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/fodder"
+	"github.com/gotwarlost/crossies/internal/hidden"
+	"github.com/gotwarlost/crossies/internal/indicators"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/wordplay"
+)
+
+const (
+	defaultTimeLimit = 15
+	maxTimeLimit     = 60
+	maxDefinition    = 4 // maximum words in a definition
+	maxFragment      = 2 // maximum words on either side of a container or reversal indicator
+	maxRunningTasks  = 8 // maximum tasks run at once
+
+	definitionScore = 100
+	indicatorScore  = 20
+	wordplayScore   = 10
+)
+
+// Kind is the kind of node in an explanation.
+type Kind string
+
+// available node kinds
+const (
+	KindAnswer     Kind = "answer"
+	KindDefinition Kind = "definition"
+	KindIndicator  Kind = "indicator"
+	KindFodder     Kind = "fodder"
+	KindAnagram    Kind = "anagram"
+	KindHidden     Kind = "hidden"
+	KindReversal   Kind = "reversal"
+	KindContainer  Kind = "container"
+)
+
+// Node is a node in the explanation of a candidate answer.
+type Node struct {
+	Kind     Kind    `json:"kind"`
+	Text     string  `json:"text"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Query is a query to analyse a cryptic clue.
+type Query struct {
+	Clue        string `json:"clue"`                  // clue text, optionally ending with an enumeration like (5)
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the answer when not part of the clue
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	TimeLimit   int    `json:"timeLimit,omitempty"`   // seconds to wait for the tools, partial results are returned after this
+	text        string
+	enumeration string
+	words       []*indicators.TaggedWord
+	offsets     []int // letter offset of each word, with an extra element for the total letters
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	text, e, ok := clue.SplitEnumeration(q.Clue)
+	q.enumeration = q.Enumeration
+	if q.enumeration == "" && ok {
+		q.enumeration = e.String()
+	}
+	q.text = text
+	q.words = indicators.Tag(text)
+	if len(q.words) < 2 {
+		return inputerror.New("clue must have at least two words")
+	}
+	q.offsets = []int{0}
+	for _, w := range q.words {
+		q.offsets = append(q.offsets, q.offsets[len(q.offsets)-1]+len(w.Letters))
+	}
+	var err error
+	q.pattern, err = clue.NewPattern(q.enumeration, q.Frame)
+	if err != nil {
+		return err
+	}
+	if q.pattern.Length() == 0 {
+		return inputerror.New("no enumeration or frame specified")
+	}
+	if q.TimeLimit <= 0 {
+		q.TimeLimit = defaultTimeLimit
+	}
+	if q.TimeLimit > maxTimeLimit {
+		q.TimeLimit = maxTimeLimit
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Clue = values.Get("clue")
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if s := values.Get("timeLimit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return q, inputerror.New(fmt.Sprintf("invalid time limit %q", s))
+		}
+		q.TimeLimit = n
+	}
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Candidate is a possible answer to the clue.
+type Candidate struct {
+	Answer      string `json:"answer"`
+	Score       int    `json:"score"`
+	Explanation *Node  `json:"explanation"`
+}
+
+// Result is the result of analysing a clue.
+type Result struct {
+	Query      *Query                   `json:"query,omitempty"`
+	Words      []*indicators.TaggedWord `json:"words"`              // clue words tagged with indicators
+	Candidates []*Candidate             `json:"candidates"`         // candidate answers, best first
+	Warnings   []string                 `json:"warnings,omitempty"` // tools that failed or did not finish in time
+}
+
+// definition is a possible definition at the start or end of the clue.
+type definition struct {
+	start, end int            // word indices
+	text       string         // definition text
+	synonyms   map[string]int // synonyms that fit the answer with their priority, set once tasks finish
+}
+
+// finding is an answer produced by a wordplay tool, or a synonym of a definition.
+type finding struct {
+	answer    string
+	node      *Node
+	start     int         // letter offset of the clue text used by the wordplay
+	end       int         // letter offset one past the end of the text used
+	indicated bool        // whether an indicator for the wordplay was found
+	def       *definition // the definition for synonyms of a definition
+	priority  int         // priority of a synonym of a definition
+}
+
+type task struct {
+	name string
+	run  func() ([]*finding, error)
+}
+
+func (q *Query) span(start, end int) string {
+	var parts []string
+	for _, w := range q.words[start:end] {
+		parts = append(parts, w.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (q *Query) definitions() []*definition {
+	var ret []*definition
+	n := len(q.words)
+	for k := 1; k <= maxDefinition && k < n; k++ {
+		ret = append(ret, &definition{start: 0, end: k, text: q.span(0, k)})
+		ret = append(ret, &definition{start: n - k, end: n, text: q.span(n-k, n)})
+	}
+	return ret
+}
+
+func (q *Query) hasIndicator(t indicators.Type) bool {
+	for _, w := range q.words {
+		if w.Has(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Query) definitionTask(d *definition) task {
+	return task{
+		name: fmt.Sprintf("synonyms of %q", d.text),
+		run: func() ([]*finding, error) {
+			syns, err := synonyms.Default()(d.text, 0)
+			if err != nil {
+				return nil, err
+			}
+			var ret []*finding
+			for _, e := range syns {
+				if q.pattern.Match(e.Synonym) {
+					ret = append(ret, &finding{answer: clue.Letters(e.Synonym), def: d, priority: e.Priority})
+				}
+			}
+			return ret, nil
+		},
+	}
+}
+
+func (q *Query) anagramTask() task {
+	return task{
+		name: "anagrams",
+		run: func() ([]*finding, error) {
+			fq := fodder.Query{Clue: q.text, Enumeration: q.enumeration, Frame: q.Frame, Solve: true}
+			res, err := fq.Run()
+			if err != nil {
+				return nil, err
+			}
+			var ret []*finding
+			for _, c := range res.Candidates {
+				for _, a := range c.Anagrams {
+					node := &Node{Kind: KindAnagram, Text: strings.ToUpper(a), Children: []*Node{
+						{Kind: KindFodder, Text: c.Fodder},
+					}}
+					if c.Indicator != "" {
+						node.Children = append(node.Children, &Node{Kind: KindIndicator, Text: c.Indicator})
+					}
+					ret = append(ret, &finding{
+						answer:    a,
+						node:      node,
+						start:     q.offsets[c.Start],
+						end:       q.offsets[c.End],
+						indicated: c.Indicator != "",
+					})
+				}
+			}
+			return ret, nil
+		},
+	}
+}
+
+func (q *Query) hiddenTask() task {
+	return task{
+		name: "hidden words",
+		run: func() ([]*finding, error) {
+			hq := hidden.Query{Clue: q.text, Enumeration: q.enumeration, Frame: q.Frame}
+			res, err := hq.Run()
+			if err != nil {
+				return nil, err
+			}
+			var ret []*finding
+			for _, e := range res.Entries {
+				kind, want := KindHidden, indicators.Hidden
+				if e.Reversed {
+					kind, want = KindReversal, indicators.Reversal
+				}
+				start := len(clue.Letters(q.text[:e.Start]))
+				ret = append(ret, &finding{
+					answer: e.Word,
+					node: &Node{Kind: kind, Text: strings.ToUpper(e.Word), Children: []*Node{
+						{Kind: KindFodder, Text: e.Span},
+					}},
+					start:     start,
+					end:       start + len(e.Word),
+					indicated: q.hasIndicator(want),
+				})
+			}
+			return ret, nil
+		},
+	}
+}
+
+// fragments returns the word ranges of up to maxFragment words immediately before and after an indicator.
+func (q *Query) fragments(index int) (before, after [][2]int) {
+	for k := 1; k <= maxFragment; k++ {
+		if index-k >= 0 {
+			before = append(before, [2]int{index - k, index})
+		}
+		if index+1+k <= len(q.words) {
+			after = append(after, [2]int{index + 1, index + 1 + k})
+		}
+	}
+	return before, after
+}
+
+func (q *Query) wordplayFindings(wq wordplay.Query, indicator int, ranges ...[2]int) ([]*finding, error) {
+	res, err := wq.Run()
+	if err != nil {
+		return nil, err
+	}
+	start, end := indicator, indicator+1
+	for _, r := range ranges {
+		if r[0] < start {
+			start = r[0]
+		}
+		if r[1] > end {
+			end = r[1]
+		}
+	}
+	var ret []*finding
+	for _, c := range res.Constructions {
+		kind := KindContainer
+		if c.Type == wordplay.TypeReversal {
+			kind = KindReversal
+		}
+		node := &Node{Kind: kind, Text: c.Derivation, Children: []*Node{
+			{Kind: KindFodder, Text: fmt.Sprintf("%s = %s", c.Outer.Part, strings.ToUpper(c.Outer.Text))},
+		}}
+		if c.Inner != nil {
+			node.Children = append(node.Children, &Node{
+				Kind: KindFodder,
+				Text: fmt.Sprintf("%s = %s", c.Inner.Part, strings.ToUpper(c.Inner.Text)),
+			})
+		}
+		node.Children = append(node.Children, &Node{Kind: KindIndicator, Text: q.words[indicator].Text})
+		ret = append(ret, &finding{
+			answer:    c.Answer,
+			node:      node,
+			start:     q.offsets[start],
+			end:       q.offsets[end],
+			indicated: true,
+		})
+	}
+	return ret, nil
+}
+
+func (q *Query) reversalTasks() []task {
+	var ret []task
+	for i, w := range q.words {
+		if !w.Has(indicators.Reversal) {
+			continue
+		}
+		before, after := q.fragments(i)
+		for _, r := range append(before, after...) {
+			i, r := i, r
+			text := q.span(r[0], r[1])
+			ret = append(ret, task{
+				name: fmt.Sprintf("reversals of %q", text),
+				run: func() ([]*finding, error) {
+					wq := wordplay.Query{Left: text, Enumeration: q.enumeration, Frame: q.Frame}
+					return q.wordplayFindings(wq, i, r)
+				},
+			})
+		}
+	}
+	return ret
+}
+
+func (q *Query) containerTasks() []task {
+	var ret []task
+	for i, w := range q.words {
+		if !w.Has(indicators.Container) {
+			continue
+		}
+		before, after := q.fragments(i)
+		for _, b := range before {
+			for _, a := range after {
+				i, b, a := i, b, a
+				left, right := q.span(b[0], b[1]), q.span(a[0], a[1])
+				ret = append(ret, task{
+					name: fmt.Sprintf("containers of %q and %q", left, right),
+					run: func() ([]*finding, error) {
+						wq := wordplay.Query{Left: left, Right: right, Enumeration: q.enumeration, Frame: q.Frame}
+						return q.wordplayFindings(wq, i, b, a)
+					},
+				})
+			}
+		}
+	}
+	return ret
+}
+
+// runTasks runs the supplied tasks concurrently, returning the findings of the tasks that finished
+// within the time limit along with warnings for the ones that failed or did not finish. At most
+// maxRunningTasks run at once and no task is started after the time limit. The tools cannot be
+// interrupted, so tasks that are running at the time limit finish in the background and their
+// findings are discarded.
+func (q *Query) runTasks(tasks []task) ([]*finding, []string) {
+	type outcome struct {
+		index    int
+		findings []*finding
+		err      error
+	}
+	queue := make(chan int, len(tasks))
+	for i := range tasks {
+		queue <- i
+	}
+	close(queue)
+	// buffered so that tasks finishing after the time limit do not block
+	ch := make(chan outcome, len(tasks))
+	stop := make(chan struct{})
+	defer close(stop)
+	workers := maxRunningTasks
+	if len(tasks) < workers {
+		workers = len(tasks)
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range queue {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				f, err := tasks[i].run()
+				ch <- outcome{index: i, findings: f, err: err}
+			}
+		}()
+	}
+	timer := time.NewTimer(time.Duration(q.TimeLimit) * time.Second)
+	defer timer.Stop()
+
+	var findings []*finding
+	var warnings []string
+	done := make([]bool, len(tasks))
+	for remaining := len(tasks); remaining > 0; remaining-- {
+		select {
+		case o := <-ch:
+			done[o.index] = true
+			if o.err != nil {
+				if code := errcode.Of(o.err); code != errcode.InvalidInput && code != errcode.NotFound {
+					warnings = append(warnings, fmt.Sprintf("%s: %v", tasks[o.index].name, o.err))
+				}
+				continue
+			}
+			findings = append(findings, o.findings...)
+		case <-timer.C:
+			for i, t := range tasks {
+				if !done[i] {
+					warnings = append(warnings, fmt.Sprintf("%s: did not finish in time", t.name))
+				}
+			}
+			return findings, warnings
+		}
+	}
+	return findings, warnings
+}
+
+func (q *Query) definitionNode(d *definition) *Node {
+	return &Node{Kind: KindDefinition, Text: d.text}
+}
+
+// Run analyses the clue, trying each definition position and dispatching the rest of the clue to the
+// wordplay tools suggested by its indicators, and returns candidate answers best first.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	defs := q.definitions()
+	var tasks []task
+	for _, d := range defs {
+		tasks = append(tasks, q.definitionTask(d))
+	}
+	tasks = append(tasks, q.anagramTask())
+	if q.hasIndicator(indicators.Hidden) || q.hasIndicator(indicators.Reversal) {
+		tasks = append(tasks, q.hiddenTask())
+	}
+	tasks = append(tasks, q.reversalTasks()...)
+	tasks = append(tasks, q.containerTasks()...)
+
+	all, warnings := q.runTasks(tasks)
+	var findings []*finding
+	for _, f := range all {
+		if f.def == nil {
+			findings = append(findings, f)
+			continue
+		}
+		if f.def.synonyms == nil {
+			f.def.synonyms = map[string]int{}
+		}
+		if p, ok := f.def.synonyms[f.answer]; !ok || f.priority < p {
+			f.def.synonyms[f.answer] = f.priority
+		}
+	}
+
+	best := map[string]*Candidate{}
+	consider := func(c *Candidate) {
+		if e, ok := best[c.Answer]; ok && e.Score >= c.Score {
+			return
+		}
+		best[c.Answer] = c
+	}
+	for _, f := range findings {
+		score := wordplayScore
+		if f.indicated {
+			score += indicatorScore
+		}
+		node := &Node{Kind: KindAnswer, Text: strings.ToUpper(f.answer), Children: []*Node{f.node}}
+		var bestDef *definition
+		for _, d := range defs {
+			if f.start < q.offsets[d.end] && q.offsets[d.start] < f.end {
+				continue // wordplay overlaps the definition
+			}
+			if _, ok := d.synonyms[f.answer]; !ok {
+				continue
+			}
+			if bestDef == nil || d.synonyms[f.answer] < bestDef.synonyms[f.answer] {
+				bestDef = d
+			}
+		}
+		if bestDef != nil {
+			score += definitionScore
+			node.Children = append([]*Node{q.definitionNode(bestDef)}, node.Children...)
+		}
+		consider(&Candidate{Answer: f.answer, Score: score, Explanation: node})
+	}
+	for _, d := range defs {
+		for syn := range d.synonyms {
+			consider(&Candidate{
+				Answer: syn,
+				Score:  1,
+				Explanation: &Node{Kind: KindAnswer, Text: strings.ToUpper(syn), Children: []*Node{
+					q.definitionNode(d),
+				}},
+			})
+		}
+	}
+
+	ret := &Result{Query: q, Words: q.words, Warnings: warnings}
+	for _, c := range best {
+		ret.Candidates = append(ret.Candidates, c)
+	}
+	sort.Slice(ret.Candidates, func(i, j int) bool {
+		left, right := ret.Candidates[i], ret.Candidates[j]
+		if left.Score != right.Score {
+			return left.Score > right.Score
+		}
+		return left.Answer < right.Answer
+	})
+	if len(ret.Candidates) == 0 && len(warnings) == 0 {
+		return nil, errcode.NoResults("no candidate answers found")
+	}
+	return ret, nil
+}
@@ -0,0 +1,131 @@
+package analyse_test
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/anagrams"
+	"github.com/gotwarlost/crossies/internal/analyse"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/synonyms/synonymstest"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSynonyms = synonymstest.Static(map[string][]string{
+		"challenge": {"dare", "test"},
+	})
+	testWords = wordlist.New([]string{"dare", "test", "read"})
+)
+
+func sortLetters(s string) string {
+	b := []byte(strings.ToLower(s))
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return string(b)
+}
+
+// testAnagrams finds anagrams in the test words.
+func testAnagrams(q anagrams.Query) (*anagrams.Result, error) {
+	var phrases []string
+	for _, w := range testWords.WithLength(len(q.Phrase)) {
+		if sortLetters(w) == sortLetters(q.Phrase) && w != strings.ToLower(q.Phrase) {
+			phrases = append(phrases, w)
+		}
+	}
+	if len(phrases) == 0 {
+		return nil, errcode.NoResults("no anagrams found for %q", q.Phrase)
+	}
+	return &anagrams.Result{Phrases: phrases}, nil
+}
+
+func TestMain(m *testing.M) {
+	synonyms.SetDefault(testSynonyms)
+	wordlist.SetDefault(testWords)
+	anagrams.SetDefault(testAnagrams)
+	os.Exit(m.Run())
+}
+
+func TestAnalyse(t *testing.T) {
+	q := analyse.Query{Clue: "Challenge partly forward areas (4)"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.Empty(t, res.Warnings)
+	require.NotEmpty(t, res.Candidates)
+
+	top := res.Candidates[0]
+	assert.Equal(t, "dare", top.Answer)
+	assert.Equal(t, 130, top.Score)
+	assert.Equal(t, &analyse.Node{Kind: analyse.KindAnswer, Text: "DARE", Children: []*analyse.Node{
+		{Kind: analyse.KindDefinition, Text: "Challenge"},
+		{Kind: analyse.KindHidden, Text: "DARE", Children: []*analyse.Node{
+			{Kind: analyse.KindFodder, Text: "d are"},
+		}},
+	}}, top.Explanation)
+
+	scores := map[string]int{}
+	for _, c := range res.Candidates {
+		scores[c.Answer] = c.Score
+	}
+	assert.Equal(t, 1, scores["test"]) // a definition with no wordplay
+}
+
+func TestAnalyseAnagram(t *testing.T) {
+	q := analyse.Query{Clue: "Challenge read wildly (4)"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.Empty(t, res.Warnings)
+	require.NotEmpty(t, res.Candidates)
+	assert.Equal(t, &analyse.Node{Kind: analyse.KindAnswer, Text: "DARE", Children: []*analyse.Node{
+		{Kind: analyse.KindDefinition, Text: "Challenge"},
+		{Kind: analyse.KindAnagram, Text: "DARE", Children: []*analyse.Node{
+			{Kind: analyse.KindFodder, Text: "read"},
+			{Kind: analyse.KindIndicator, Text: "wildly"},
+		}},
+	}}, res.Candidates[0].Explanation)
+}
+
+func TestAnalyseErrors(t *testing.T) {
+	q := analyse.Query{Clue: "Zzz qqq (9)"}
+	_, err := q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	for _, q := range []analyse.Query{{Clue: "Challenge (4)"}, {Clue: "Partly forward areas"}} {
+		_, err = q.Run()
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err), q.Clue)
+	}
+}
+
+func TestAnalyseTimeLimit(t *testing.T) {
+	var l sync.Mutex
+	calls := 0
+	release := make(chan struct{})
+	blocking := func(string, int) ([]*synonyms.Entry, error) {
+		l.Lock()
+		calls++
+		l.Unlock()
+		<-release
+		return nil, errcode.New(errcode.NotFound, "no synonyms")
+	}
+	// a clue with more definitions than the tasks that are run at once
+	q := analyse.Query{Clue: "One two three four five six seven (4)", TimeLimit: 1}
+	synonyms.SetDefault(blocking)
+	defer synonyms.SetDefault(testSynonyms)
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.Empty(t, res.Candidates)
+	assert.Len(t, res.Warnings, 9) // every definition and the anagrams
+	assert.Contains(t, res.Warnings[0], "did not finish in time")
+
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	l.Lock()
+	defer l.Unlock()
+	assert.Equal(t, 8, calls, "no tasks are started after the time limit")
+}
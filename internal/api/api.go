@@ -2,24 +2,44 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
 
-	"github.com/gotwarlost/crossies/internal/anagrams"
-	"github.com/gotwarlost/crossies/internal/findwords"
+	"github.com/gotwarlost/crossies/internal/collab"
+	"github.com/gotwarlost/crossies/internal/errcode"
 	"github.com/gotwarlost/crossies/internal/inputerror"
-	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/session"
 )
 
+const maxBodySize = 1 << 20 // largest JSON query body accepted
+
+// Options configures the optional parts of the API.
+type Options struct {
+	Sessions *session.Store // store for solving sessions, session endpoints are disabled when nil
+}
+
 type Handler struct {
-	h http.Handler
+	h        http.Handler
+	sessions *session.Store
+	hub      *collab.Hub
 }
 
-func New() (*Handler, error) {
+func New(opts Options) (*Handler, error) {
 	mux := http.NewServeMux()
-	ret := &Handler{}
-	mux.Handle("/v1/synonyms", http.HandlerFunc(ret.synonyms))
-	mux.Handle("/v1/matching-words", http.HandlerFunc(ret.findMatchingWords))
-	mux.Handle("/v1/anagrams", http.HandlerFunc(ret.solveAnagram))
+	ret := &Handler{sessions: opts.Sessions}
+	for name, t := range tools {
+		mux.Handle("/v1/"+name, ret.v1(t))
+	}
+	mux.Handle("/v1/batch", http.HandlerFunc(ret.runQueries))
+	mux.Handle("/v2/", http.HandlerFunc(ret.v2))
+	if ret.sessions != nil {
+		ret.hub = collab.NewHub(ret.sessions)
+		mux.Handle("/v1/sessions", http.HandlerFunc(ret.createSession))
+		mux.Handle("/v1/sessions/", http.HandlerFunc(ret.sessionRoutes))
+	}
 	ret.h = mux
 	return ret, nil
 }
@@ -28,85 +48,42 @@ func (h *Handler) HTTPHandler() http.Handler {
 	return h.h
 }
 
-func (h *Handler) sendError(w http.ResponseWriter, msg string, code int) {
+// sendError sends an error as JSON with the HTTP status for its code.
+func (h *Handler) sendError(w http.ResponseWriter, err error) {
+	code := errcode.Of(err)
+	status := errcode.HTTPStatus(code)
 	ret := map[string]interface{}{
-		"error": msg,
-		"code":  code,
+		"error":  err.Error(),
+		"code":   code,
+		"status": status,
 	}
 	b, _ := json.Marshal(ret)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	_, _ = w.Write(b)
-}
-
-func (h *Handler) synonyms(w http.ResponseWriter, r *http.Request) {
-	q, err := synonyms.NewQueryFromParams(r.URL.Query())
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	syns, err := q.Run()
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	b, err := json.Marshal(syns)
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	w.WriteHeader(status)
 	_, _ = w.Write(b)
 }
 
-func (h *Handler) findMatchingWords(w http.ResponseWriter, r *http.Request) {
-	q, err := findwords.NewQueryFromParams(r.URL.Query())
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	result, err := q.Run()
-	if err != nil {
-		if ok := inputerror.IsInputError(err); ok {
-			h.sendError(w, err.Error(), http.StatusBadRequest)
-		} else {
-			h.sendError(w, err.Error(), http.StatusInternalServerError)
-		}
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-	b, err := json.Marshal(result)
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
-		return
+// isJSON returns true if the query is posted as a JSON body rather than passed as parameters.
+func isJSON(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
 	}
-	_, _ = w.Write(b)
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return ct == "application/json"
 }
 
-func (h *Handler) solveAnagram(w http.ResponseWriter, r *http.Request) {
-	q, err := anagrams.NewQueryFromParams(r.URL.Query())
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	result, err := anagrams.Solve(q)
-	if err != nil {
-		if ok := inputerror.IsInputError(err); ok {
-			h.sendError(w, err.Error(), http.StatusBadRequest)
-		} else {
-			h.sendError(w, err.Error(), http.StatusInternalServerError)
+// decodeJSON decodes a JSON request body into a query, rejecting unknown fields and bodies that are too large.
+func decodeJSON(w http.ResponseWriter, r *http.Request, q interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(q); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			return inputerror.New(fmt.Sprintf("request body is larger than %d bytes", maxBodySize))
 		}
-		return
+		return inputerror.New("invalid JSON body: " + err.Error())
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	b, err := json.Marshal(result)
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
-		return
+	if _, err := dec.Token(); err != io.EOF {
+		return inputerror.New("invalid JSON body: unexpected data after query")
 	}
-	_, _ = w.Write(b)
+	return nil
 }
@@ -0,0 +1,306 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/api"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/synonyms/synonymstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, r *http.Request) (int, map[string]interface{}) {
+	h, err := api.New(api.Options{})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	h.HTTPHandler().ServeHTTP(w, r)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func postJSON(body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/grid/check", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return r
+}
+
+func TestJSONBody(t *testing.T) {
+	code, body := serve(t, postJSON(`{"grid": "...#/.#../..#./#...", "minLength": 2}`))
+	require.Equal(t, http.StatusOK, code, body)
+
+	// the same query as parameters gives the same result
+	r := httptest.NewRequest(http.MethodGet, "/v1/grid/check?"+url.Values{
+		"grid":      {"...#/.#../..#./#..."},
+		"minLength": {"2"},
+	}.Encode(), nil)
+	code, params := serve(t, r)
+	require.Equal(t, http.StatusOK, code, params)
+	assert.Equal(t, params, body)
+}
+
+func TestJSONBodyErrors(t *testing.T) {
+	tests := []struct {
+		name, body, msg string
+	}{
+		{"unknown field", `{"grid": "...", "size": 3}`, `unknown field "size"`},
+		{"bad syntax", `{"grid": `, "invalid JSON body"},
+		{"trailing data", `{"grid": "..."} {}`, "unexpected data after query"},
+		{"too large", `{"grid": "` + strings.Repeat(".", 2<<20) + `"}`, "request body is larger than"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, body := serve(t, postJSON(test.body))
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Equal(t, "invalid_input", body["code"])
+			assert.Contains(t, body["error"], test.msg)
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/batch", strings.NewReader(`[
+		{"type": "grid/check", "query": {"grid": "...#/.#../..#./#...", "minLength": 2}},
+		{"type": "grid/check", "query": {"grid": "..?/..."}},
+		{"type": "teleport", "query": {}},
+		{"type": "parse-clues", "query": {"text": "1 Clue (4)", "extra": true}}
+	]`))
+	code, body := serve(t, r)
+	require.Equal(t, http.StatusOK, code, body)
+	items := body["items"].([]interface{})
+	require.Len(t, items, 4)
+
+	first := items[0].(map[string]interface{})
+	assert.Equal(t, "grid/check", first["type"])
+	assert.NotNil(t, first["result"])
+	assert.Nil(t, first["error"])
+
+	for _, item := range items[1:] {
+		e := item.(map[string]interface{})["error"].(map[string]interface{})
+		assert.Equal(t, "invalid_input", e["code"])
+		assert.EqualValues(t, http.StatusBadRequest, e["status"])
+	}
+	assert.Contains(t, items[2].(map[string]interface{})["error"].(map[string]interface{})["error"], "teleport")
+}
+
+func TestBatchErrors(t *testing.T) {
+	code, body := serve(t, httptest.NewRequest(http.MethodGet, "/v1/batch", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, code, body)
+
+	code, body = serve(t, httptest.NewRequest(http.MethodPost, "/v1/batch", strings.NewReader(`[]`)))
+	assert.Equal(t, http.StatusBadRequest, code, body)
+
+	many := "[" + strings.Repeat(`{"type": "synonyms", "query": {}},`, 20) + `{"type": "synonyms", "query": {}}]`
+	code, body = serve(t, httptest.NewRequest(http.MethodPost, "/v1/batch", strings.NewReader(many)))
+	assert.Equal(t, http.StatusBadRequest, code, body)
+}
+
+// wordFinder serves pages of words in the format of thewordfinder.com, with total words in all, or fails with
+// status if it is set.
+type wordFinder struct {
+	total  int
+	status int
+}
+
+func (f *wordFinder) RoundTrip(r *http.Request) (*http.Response, error) {
+	if f.status != 0 {
+		return &http.Response{StatusCode: f.status, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("pg"))
+	var b strings.Builder
+	fmt.Fprintf(&b, `<div class="word-criteria-heading">There are %d words</div><div class="word-results"><ul>`, f.total)
+	for i := (page - 1) * 250; i < page*250 && i < f.total; i++ {
+		fmt.Fprintf(&b, `<li class="word"><a><span>W%d (3)</span></a></li>`, i)
+	}
+	b.WriteString("</ul></div>")
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       io.NopCloser(strings.NewReader(b.String())),
+		Request:    r,
+	}, nil
+}
+
+func TestStream(t *testing.T) {
+	client := http.DefaultClient
+	http.DefaultClient = &http.Client{Transport: &wordFinder{total: 300}}
+	defer func() { http.DefaultClient = client }()
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/matching-words?frame=w..&stream=ndjson", nil)
+	h, err := api.New(api.Options{})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	h.HTTPHandler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed)
+
+	var events []string
+	var words int
+	dec := json.NewDecoder(w.Body)
+	for dec.More() {
+		var event struct {
+			Event string                 `json:"event"`
+			Data  map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, dec.Decode(&event))
+		events = append(events, event.Event)
+		switch event.Event {
+		case "progress":
+			assert.EqualValues(t, 2, event.Data["totalPages"])
+			assert.EqualValues(t, 300, event.Data["totalWords"])
+		case "result":
+			words += len(event.Data["words"].([]interface{}))
+		}
+	}
+	assert.Equal(t, []string{"progress", "result", "progress", "result", "done"}, events)
+	assert.Equal(t, 300, words)
+}
+
+func TestStreamErrors(t *testing.T) {
+	// errors in the query are reported before streaming starts
+	for _, path := range []string{
+		"/v1/matching-words?frame=...&stream=ndjson",
+		"/v1/anagrams?stream=sse",
+		"/v1/anagrams?phrase=dormitory&stream=sse",
+	} {
+		code, body := serve(t, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, code, path)
+		assert.Equal(t, "invalid_input", body["code"], path)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/matching-words?frame=...", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	code, body := serve(t, r)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body["error"], "all be dots")
+}
+
+func TestV2Pages(t *testing.T) {
+	clues := "Across\n1 First clue (4)\n2 Second clue (5)\n3 Third clue (6)\n"
+	get := func(cursor string) (int, map[string]interface{}) {
+		v := url.Values{"text": {clues}, "limit": {"2"}}
+		if cursor != "" {
+			v.Set("cursor", cursor)
+		}
+		return serve(t, httptest.NewRequest(http.MethodGet, "/v2/parse-clues?"+v.Encode(), nil))
+	}
+	code, body := get("")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Len(t, body["items"], 2)
+	assert.EqualValues(t, 3, body["total"])
+	assert.Equal(t, "local", body["provider"])
+	assert.Equal(t, []interface{}{}, body["warnings"])
+	assert.Contains(t, body["timing"], "elapsedMs")
+	cursor := body["cursor"].(string)
+	require.NotEmpty(t, cursor)
+
+	code, body = get(cursor)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Len(t, body["items"], 1)
+	assert.Equal(t, "", body["cursor"])
+
+	// cursors only work with the query they came from
+	v := url.Values{"text": {"1 Other clue (5)"}, "cursor": {cursor}}
+	code, body = serve(t, httptest.NewRequest(http.MethodGet, "/v2/parse-clues?"+v.Encode(), nil))
+	assert.Equal(t, http.StatusBadRequest, code, body)
+	code, body = get("not-a-cursor")
+	assert.Equal(t, http.StatusBadRequest, code, body)
+}
+
+func TestV2Empty(t *testing.T) {
+	r := postJSON(`{"word": "xqzzy"}`)
+	r.URL.Path = "/v2/abbreviations"
+	code, body := serve(t, r)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, []interface{}{}, body["items"])
+	assert.EqualValues(t, 0, body["total"])
+	assert.Equal(t, []interface{}{`no abbreviations found for "xqzzy"`}, body["warnings"])
+
+	// invalid queries are still errors
+	r = postJSON(`{"word": ""}`)
+	r.URL.Path = "/v2/abbreviations"
+	code, body = serve(t, r)
+	assert.Equal(t, http.StatusBadRequest, code, body)
+
+	code, body = serve(t, httptest.NewRequest(http.MethodGet, "/v2/grid/check", nil))
+	assert.Equal(t, http.StatusNotFound, code, body)
+}
+
+func TestV2Failures(t *testing.T) {
+	upstream := &wordFinder{total: 300}
+	client := http.DefaultClient
+	http.DefaultClient = &http.Client{Transport: upstream}
+	defer func() { http.DefaultClient = client }()
+
+	code, body := serve(t, httptest.NewRequest(http.MethodGet, "/v2/matching-words?frame=w..", nil))
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, "thewordfinder.com", body["provider"])
+	cursor := body["cursor"].(string)
+	require.NotEmpty(t, cursor)
+
+	// a cursor past the last page is not the same as finding nothing
+	upstream.total = 200
+	code, body = serve(t, httptest.NewRequest(http.MethodGet, "/v2/matching-words?frame=w..&cursor="+cursor, nil))
+	assert.Equal(t, http.StatusNotFound, code, body)
+	assert.Contains(t, body["error"], "past the last page")
+
+	// nor is a missing upstream page
+	upstream.status = http.StatusNotFound
+	code, body = serve(t, httptest.NewRequest(http.MethodGet, "/v2/synonyms?word=cat", nil))
+	assert.Equal(t, http.StatusNotFound, code, body)
+	assert.Equal(t, "not_found", body["code"])
+}
+
+func TestV2Providers(t *testing.T) {
+	code, body := serve(t, httptest.NewRequest(http.MethodGet, "/v2/fodder?clue=Broken+ring+(4)", nil))
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, "local", body["provider"])
+
+	defer synonyms.SetDefault(synonyms.Default())
+	synonyms.SetDefault(synonymstest.Static(map[string][]string{"donkey": {"ass"}}))
+	code, body = serve(t, httptest.NewRequest(http.MethodGet, "/v2/wordplay?left=donkey&rightWord=pion&frame=pa....n", nil))
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Len(t, body["items"], 1)
+	assert.Equal(t, "wordhippo.com", body["provider"])
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name, method, path string
+		status             int
+		code               string
+	}{
+		{"invalid input", http.MethodGet, "/v1/grid/check", http.StatusBadRequest, "invalid_input"},
+		{"not found", http.MethodGet, "/v2/nothing", http.StatusNotFound, "not_found"},
+		{"method not allowed", http.MethodGet, "/v1/batch", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"no pronouncing dictionary", http.MethodGet, "/v1/homophones?word=knight", http.StatusNotImplemented, "not_configured"},
+		{"no clue database", http.MethodGet, "/v1/clues?text=knight", http.StatusNotImplemented, "not_configured"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, body := serve(t, httptest.NewRequest(test.method, test.path, nil))
+			assert.Equal(t, test.status, code, body)
+			assert.Equal(t, test.code, body["code"])
+			assert.EqualValues(t, test.status, body["status"])
+		})
+	}
+}
+
+func TestTooManySynonyms(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/matching-words",
+		strings.NewReader(`{"frame": "c.t", "synonyms": ["a", "b", "c", "d", "e", "f"]}`))
+	r.Header.Set("Content-Type", "application/json")
+	code, body := serve(t, r)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body["error"], "at most 5 synonyms")
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+)
+
+const (
+	maxBatchSize        = 20
+	defaultBatchTimeout = 20 * time.Second
+	maxBatchTimeout     = 55 * time.Second
+	maxRunningQueries   = 4 // maximum queries of a batch run at once
+)
+
+// batchRequest is a single query in a batch.
+type batchRequest struct {
+	Type  string          `json:"type"`  // path of the tool's endpoint under /v1/, like "synonyms"
+	Query json.RawMessage `json:"query"` // the query the tool's endpoint accepts as a JSON body
+}
+
+// batchError is the error of a failed query in a batch, as it would be returned by the tool's endpoint.
+type batchError struct {
+	Error  string       `json:"error"`
+	Code   errcode.Code `json:"code"`
+	Status int          `json:"status"`
+}
+
+// batchItem is the outcome of a query in a batch, which has either a result or an error.
+type batchItem struct {
+	Type   string      `json:"type"`
+	Result interface{} `json:"result,omitempty"`
+	Error  *batchError `json:"error,omitempty"`
+}
+
+func (b *batchItem) setError(err error) {
+	code := errcode.Of(err)
+	b.Result = nil
+	b.Error = &batchError{Error: err.Error(), Code: code, Status: errcode.HTTPStatus(code)}
+}
+
+// runBatchRequest runs a single query of a batch.
+func runBatchRequest(req batchRequest) (interface{}, error) {
+	t, ok := tools[req.Type]
+	if !ok {
+		return nil, inputerror.New(fmt.Sprintf("unknown query type %q", req.Type))
+	}
+	if len(req.Query) == 0 {
+		return nil, inputerror.New("no query specified")
+	}
+	q := t.newQuery()
+	dec := json.NewDecoder(bytes.NewReader(req.Query))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(q); err != nil {
+		return nil, inputerror.New("invalid query: " + err.Error())
+	}
+	return t.run(q)
+}
+
+// runBatch runs the queries concurrently and returns their outcomes in order. At most maxRunningQueries run at
+// once and no query is started once the context is done, so queries that have not finished by then fail with a
+// timeout. The tools cannot be interrupted, so queries that are running at the deadline finish in the background
+// and their results are discarded.
+func runBatch(ctx context.Context, reqs []batchRequest) []*batchItem {
+	type outcome struct {
+		index  int
+		result interface{}
+		err    error
+	}
+	items := make([]*batchItem, len(reqs))
+	queue := make(chan int, len(reqs))
+	for i, req := range reqs {
+		items[i] = &batchItem{Type: req.Type}
+		queue <- i
+	}
+	close(queue)
+	// buffered so that queries finishing after the deadline do not block
+	ch := make(chan outcome, len(reqs))
+	workers := maxRunningQueries
+	if len(reqs) < workers {
+		workers = len(reqs)
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range queue {
+				if ctx.Err() != nil {
+					return
+				}
+				result, err := runBatchRequest(reqs[i])
+				ch <- outcome{index: i, result: result, err: err}
+			}
+		}()
+	}
+	done := make([]bool, len(reqs))
+	for range reqs {
+		select {
+		case o := <-ch:
+			done[o.index] = true
+			if o.err != nil {
+				items[o.index].setError(o.err)
+			} else {
+				items[o.index].Result = o.result
+			}
+		case <-ctx.Done():
+			for i, item := range items {
+				if !done[i] {
+					item.setError(errcode.Wrap(ctx.Err(), errcode.Timeout, "query did not finish"))
+				}
+			}
+			return items
+		}
+	}
+	return items
+}
+
+// batchTimeout returns the shared deadline of a batch from the "timeout" parameter in seconds.
+func batchTimeout(r *http.Request) (time.Duration, error) {
+	str := r.URL.Query().Get("timeout")
+	if str == "" {
+		return defaultBatchTimeout, nil
+	}
+	secs, err := strconv.Atoi(str)
+	if err != nil || secs <= 0 {
+		return 0, inputerror.New(fmt.Sprintf("invalid timeout %q", str))
+	}
+	timeout := time.Duration(secs) * time.Second
+	if timeout > maxBatchTimeout {
+		timeout = maxBatchTimeout
+	}
+	return timeout, nil
+}
+
+// runQueries runs a JSON array of typed queries concurrently with a shared deadline, and returns the result or
+// error of each in order. A failing query does not fail the batch.
+func (h *Handler) runQueries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendError(w, errcode.New(errcode.MethodNotAllowed, "batches must be posted"))
+		return
+	}
+	timeout, err := batchTimeout(r)
+	if err != nil {
+		h.sendError(w, err)
+		return
+	}
+	var reqs []batchRequest
+	if err := decodeJSON(w, r, &reqs); err != nil {
+		h.sendError(w, err)
+		return
+	}
+	if len(reqs) == 0 {
+		h.sendError(w, inputerror.New("no queries specified"))
+		return
+	}
+	if len(reqs) > maxBatchSize {
+		h.sendError(w, inputerror.New(fmt.Sprintf("at most %d queries may be batched", maxBatchSize)))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	items := runBatch(ctx, reqs)
+
+	w.Header().Set("Content-Type", "application/json")
+	b, err := json.Marshal(map[string]interface{}{"items": items})
+	if err != nil {
+		h.sendError(w, err)
+		return
+	}
+	_, _ = w.Write(b)
+}
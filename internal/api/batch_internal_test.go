@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var calls int32
+	tools["slow"] = &tool{
+		newQuery: func() interface{} { return &struct{}{} },
+		run: func(q interface{}) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return "late", nil
+		},
+	}
+	defer delete(tools, "slow")
+
+	reqs := make([]batchRequest, maxRunningQueries+2)
+	for i := range reqs {
+		reqs[i] = batchRequest{Type: "slow", Query: json.RawMessage(`{}`)}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	items := runBatch(ctx, reqs)
+	require.Len(t, items, len(reqs))
+	for _, item := range items {
+		assert.Nil(t, item.Result)
+		require.NotNil(t, item.Error)
+		assert.Equal(t, errcode.Timeout, item.Error.Code)
+	}
+	// queries still waiting when the deadline passes are never started
+	assert.EqualValues(t, maxRunningQueries, atomic.LoadInt32(&calls))
+}
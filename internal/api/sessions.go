@@ -0,0 +1,352 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/session"
+)
+
+const (
+	maxUploadSize   = 2 << 20
+	defaultPollWait = 25 * time.Second
+	maxPollWait     = 55 * time.Second
+)
+
+// sendResult sends the result of a session operation as JSON, or the error with a status based on its kind.
+func (h *Handler) sendResult(w http.ResponseWriter, result interface{}, err error) {
+	if err != nil {
+		h.sendError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	b, err := json.Marshal(result)
+	if err != nil {
+		h.sendError(w, err)
+		return
+	}
+	_, _ = w.Write(b)
+}
+
+// uploadedSession returns a new session from the request. Puzzle files may be posted as the "puzzle" field of a
+// multipart form or as the raw request body, and clue lists as the "clues" form field.
+func uploadedSession(r *http.Request) (*session.Session, error) {
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch ct {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+			return nil, inputerror.New("invalid form: " + err.Error())
+		}
+		f, header, err := r.FormFile("puzzle")
+		if err == http.ErrMissingFile {
+			break
+		}
+		if err != nil {
+			return nil, inputerror.New("invalid puzzle upload: " + err.Error())
+		}
+		defer func() { _ = f.Close() }()
+		format, _ := puzzle.FormatForFile(header.Filename)
+		p, err := puzzle.Read(f, format)
+		if err != nil {
+			return nil, err
+		}
+		return session.NewFromPuzzle(p), nil
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return nil, inputerror.New("invalid form: " + err.Error())
+		}
+	default:
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, inputerror.New("read upload: " + err.Error())
+		}
+		if len(b) > 0 {
+			p, err := puzzle.Read(bytes.NewReader(b), puzzle.Format(r.URL.Query().Get("format")))
+			if err != nil {
+				return nil, err
+			}
+			return session.NewFromPuzzle(p), nil
+		}
+	}
+	clues := r.FormValue("clues")
+	if strings.TrimSpace(clues) == "" {
+		return nil, inputerror.New("no puzzle or clues uploaded")
+	}
+	return session.NewFromClues(clues)
+}
+
+func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendError(w, errcode.New(errcode.MethodNotAllowed, "sessions must be created with POST"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	sess, err := uploadedSession(r)
+	if err != nil {
+		h.sendResult(w, nil, err)
+		return
+	}
+	sess, err = h.sessions.Create(sess)
+	if err != nil {
+		h.sendResult(w, nil, err)
+		return
+	}
+	h.sendResult(w, sess.View(), nil)
+}
+
+// sessionRoutes handles /v1/sessions/{id} and the tools under it.
+func (h *Handler) sessionRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/sessions/"), "/")
+	id, action := parts[0], ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	if len(parts) > 2 {
+		h.sendError(w, errcode.Errorf(errcode.NotFound, "unknown session endpoint %q", r.URL.Path))
+		return
+	}
+	switch action {
+	case "":
+		if r.Method == http.MethodDelete {
+			err := h.sessions.Delete(id)
+			h.sendResult(w, map[string]string{"deleted": id}, err)
+			return
+		}
+		sess, err := h.sessions.Get(id)
+		if err != nil {
+			h.sendResult(w, nil, err)
+			return
+		}
+		h.sendResult(w, sess.View(), nil)
+	case "fill":
+		h.fillSession(w, r, id)
+	case "matching-words", "anagrams", "synonyms":
+		h.sessionTool(w, r, id, action)
+	case "events":
+		h.sessionEvents(w, r, id)
+	case "ws":
+		h.sessionWebSocket(w, r, id)
+	default:
+		h.sendError(w, errcode.Errorf(errcode.NotFound, "unknown session endpoint %q", r.URL.Path))
+	}
+}
+
+// fillRequest is the fill of a slot, or of a single square when no slot is given.
+type fillRequest struct {
+	Slot  string `json:"slot,omitempty"`
+	Fill  string `json:"fill,omitempty"`
+	Row   *int   `json:"row,omitempty"`
+	Col   *int   `json:"col,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+func fillFromParams(values url.Values) fillRequest {
+	f := fillRequest{Slot: values.Get("slot"), Fill: values.Get("fill"), Value: values.Get("value")}
+	if row, err := strconv.Atoi(values.Get("row")); err == nil {
+		f.Row = &row
+	}
+	if col, err := strconv.Atoi(values.Get("col")); err == nil {
+		f.Col = &col
+	}
+	return f
+}
+
+// fillSession sets the fill of a slot from the "slot" and "fill" parameters, or of a single square from
+// the "row", "col" and "value" parameters. The parameters may also be posted as a JSON object.
+func (h *Handler) fillSession(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		h.sendError(w, errcode.New(errcode.MethodNotAllowed, "fill must be updated with POST"))
+		return
+	}
+	var f fillRequest
+	var err error
+	if isJSON(r) {
+		err = decodeJSON(w, r, &f)
+	} else if err = r.ParseForm(); err == nil {
+		f = fillFromParams(r.Form)
+	}
+	if err != nil {
+		h.sendError(w, errcode.WithDefault(err, errcode.InvalidInput))
+		return
+	}
+	sess, err := h.sessions.Update(id, func(sess *session.Session) error {
+		if f.Slot != "" {
+			return sess.Fill(f.Slot, f.Fill)
+		}
+		if f.Row == nil || f.Col == nil {
+			return inputerror.New("slot, or row and column, must be specified")
+		}
+		return sess.SetCell(*f.Row, *f.Col, f.Value)
+	})
+	if err != nil {
+		h.sendResult(w, nil, err)
+		return
+	}
+	h.hub.Notify(id)
+	h.sendResult(w, sess.View(), nil)
+}
+
+// toolRequest holds the parameters of the tools run against a slot.
+type toolRequest struct {
+	Slot   string `json:"slot"`
+	Page   int    `json:"page,omitempty"`
+	Phrase string `json:"phrase,omitempty"`
+	Word   string `json:"word,omitempty"`
+	All    bool   `json:"all,omitempty"`
+}
+
+// sessionTool runs find-words, anagrams or synonyms with the frame of the slot in the "slot" parameter.
+// The parameters may also be posted as a JSON object.
+func (h *Handler) sessionTool(w http.ResponseWriter, r *http.Request, id string, tool string) {
+	var t toolRequest
+	if isJSON(r) {
+		if err := decodeJSON(w, r, &t); err != nil {
+			h.sendError(w, err)
+			return
+		}
+	} else {
+		values := r.URL.Query()
+		page, _ := strconv.Atoi(values.Get("page"))
+		t = toolRequest{
+			Slot:   values.Get("slot"),
+			Page:   page,
+			Phrase: values.Get("phrase"),
+			Word:   values.Get("word"),
+			All:    values.Get("all") == "true",
+		}
+	}
+	sess, err := h.sessions.Get(id)
+	if err != nil {
+		h.sendResult(w, nil, err)
+		return
+	}
+	if t.Slot == "" {
+		h.sendError(w, inputerror.New("no slot specified"))
+		return
+	}
+	switch tool {
+	case "matching-words":
+		result, err := sess.FindWords(t.Slot, t.Page)
+		h.sendResult(w, result, err)
+	case "anagrams":
+		result, err := sess.Anagrams(t.Slot, t.Phrase)
+		h.sendResult(w, result, err)
+	case "synonyms":
+		result, err := sess.Synonyms(t.Slot, t.Word, t.All)
+		h.sendResult(w, result, err)
+	}
+}
+
+// sinceParam returns the "since" parameter, -1 when it is missing so that a snapshot is returned.
+func sinceParam(values url.Values) (int64, error) {
+	str := values.Get("since")
+	if str == "" {
+		return -1, nil
+	}
+	since, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, inputerror.New(fmt.Sprintf("invalid since %q", str))
+	}
+	return since, nil
+}
+
+// sessionEvents is the long polling alternative to the WebSocket. GET waits for changes after the "since"
+// sequence for up to "wait" seconds, and POST applies a single operation described by form parameters or
+// a JSON object.
+func (h *Handler) sessionEvents(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method == http.MethodPost {
+		var op session.Op
+		var err error
+		if isJSON(r) {
+			if err = decodeJSON(w, r, &op); err == nil && op.Client == "" {
+				err = inputerror.New("no client name specified")
+			}
+		} else if err = r.ParseForm(); err == nil {
+			op, err = opFromParams(r.Form)
+		}
+		if err != nil {
+			h.sendError(w, errcode.WithDefault(err, errcode.InvalidInput))
+			return
+		}
+		events, err := h.hub.Apply(id, op)
+		h.sendResult(w, map[string]interface{}{"events": events}, err)
+		return
+	}
+	values := r.URL.Query()
+	since, err := sinceParam(values)
+	if err != nil {
+		h.sendResult(w, nil, err)
+		return
+	}
+	wait := defaultPollWait
+	if str := values.Get("wait"); str != "" {
+		secs, err := strconv.Atoi(str)
+		if err != nil || secs < 0 {
+			h.sendError(w, inputerror.New(fmt.Sprintf("invalid wait %q", str)))
+			return
+		}
+		wait = time.Duration(secs) * time.Second
+	}
+	if wait > maxPollWait {
+		wait = maxPollWait
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	result, err := h.hub.Wait(ctx, id, since)
+	h.sendResult(w, result, err)
+}
+
+func opFromParams(values url.Values) (op session.Op, _ error) {
+	op.Type = values.Get("type")
+	op.Client = values.Get("client")
+	op.Value = values.Get("value")
+	op.Pencil = values.Get("pencil") == "true"
+	op.Direction = puzzle.Direction(values.Get("direction"))
+	if op.Client == "" {
+		return op, inputerror.New("no client name specified")
+	}
+	var err error
+	for name, dest := range map[string]*int{"row": &op.Row, "col": &op.Col} {
+		if *dest, err = strconv.Atoi(values.Get(name)); err != nil && op.Type != session.OpLeave {
+			return op, inputerror.New(fmt.Sprintf("invalid %s %q", name, values.Get(name)))
+		}
+	}
+	if str := values.Get("base"); str != "" {
+		if op.Base, err = strconv.ParseInt(str, 10, 64); err != nil {
+			return op, inputerror.New(fmt.Sprintf("invalid base %q", str))
+		}
+	}
+	return op, nil
+}
+
+// sessionWebSocket connects a solver named by the "client" parameter to the session over a WebSocket.
+func (h *Handler) sessionWebSocket(w http.ResponseWriter, r *http.Request, id string) {
+	values := r.URL.Query()
+	client := values.Get("client")
+	if client == "" {
+		h.sendError(w, inputerror.New("no client name specified"))
+		return
+	}
+	since, err := sinceParam(values)
+	if err != nil {
+		h.sendResult(w, nil, err)
+		return
+	}
+	if _, err := h.sessions.Get(id); err != nil {
+		h.sendResult(w, nil, err)
+		return
+	}
+	h.hub.WebSocket(id, client, since).ServeHTTP(w, r)
+}
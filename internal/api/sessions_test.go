@@ -0,0 +1,61 @@
+package api_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/api"
+	"github.com/gotwarlost/crossies/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveSessions(t *testing.T, r *http.Request) *httptest.ResponseRecorder {
+	store, err := session.NewStore(t.TempDir(), time.Hour, 0)
+	require.NoError(t, err)
+	h, err := api.New(api.Options{Sessions: store})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	h.HTTPHandler().ServeHTTP(w, r)
+	return w
+}
+
+func TestUploadOversizedPuzzle(t *testing.T) {
+	ipuz := `{"kind":["http://ipuz.org/crossword#1"],"dimensions":{"width":100000,"height":100000},"puzzle":[]}`
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, err := mw.CreateFormFile("puzzle", "huge.ipuz")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(ipuz))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	r := httptest.NewRequest(http.MethodPost, "/v1/sessions", &form)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := serveSessions(t, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "invalid dimensions")
+
+	// a small zip that expands to more XML than a puzzle needs
+	var z bytes.Buffer
+	zw := zip.NewWriter(&z)
+	xw, err := zw.Create("puzzle.xml")
+	require.NoError(t, err)
+	_, err = xw.Write(bytes.Repeat([]byte(" "), 10<<20+1))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	w = serveSessions(t, httptest.NewRequest(http.MethodPost, "/v1/sessions?format=jpz", &z))
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "larger than")
+
+	jpz := `<rectangular-puzzle><crossword><grid width="100000" height="100000"/></crossword></rectangular-puzzle>`
+	w = serveSessions(t, httptest.NewRequest(http.MethodPost, "/v1/sessions?format=jpz", strings.NewReader(jpz)))
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "invalid dimensions")
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/findwords"
+)
+
+// maxStreamPages is the most pages of matching words that are streamed for a single request.
+const maxStreamPages = 20
+
+// stream formats
+const (
+	streamSSE    = "sse"    // server-sent events, for browsers
+	streamNDJSON = "ndjson" // newline delimited JSON, for the CLI and scripts
+)
+
+// streamFormat returns the format in which results should be streamed, from the "stream" parameter or else the
+// Accept header, or "" when the whole result should be sent at once.
+func streamFormat(r *http.Request) string {
+	switch s := r.URL.Query().Get("stream"); s {
+	case streamSSE, streamNDJSON:
+		return s
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, _ := mime.ParseMediaType(strings.TrimSpace(accept))
+		switch mt {
+		case "text/event-stream":
+			return streamSSE
+		case "application/x-ndjson":
+			return streamNDJSON
+		}
+	}
+	return ""
+}
+
+// eventWriter writes events to a streamed response, flushing each as it is written.
+type eventWriter struct {
+	ctx    context.Context
+	w      http.ResponseWriter
+	format string
+}
+
+// newEventWriter starts a streamed response in the supplied format.
+func newEventWriter(w http.ResponseWriter, r *http.Request, format string) *eventWriter {
+	h := w.Header()
+	if format == streamSSE {
+		h.Set("Content-Type", "text/event-stream")
+	} else {
+		h.Set("Content-Type", "application/x-ndjson")
+	}
+	h.Set("Cache-Control", "no-cache")
+	// stop nginx from buffering the stream. Apache's mod_fcgid buffers FastCGI output regardless of headers, so
+	// it must be configured with "FcgidOutputBufferSize 0" for events to arrive as they are sent.
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	e := &eventWriter{ctx: r.Context(), w: w, format: format}
+	e.flush()
+	return e
+}
+
+func (e *eventWriter) flush() {
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// send writes an event with JSON data. It fails once the client has gone away.
+func (e *eventWriter) send(event string, data interface{}) error {
+	if err := e.ctx.Err(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if e.format == streamSSE {
+		_, err = fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, b)
+	} else {
+		_, err = fmt.Fprintf(e.w, "{\"event\":%q,\"data\":%s}\n", event, b)
+	}
+	if err != nil {
+		return err
+	}
+	e.flush()
+	return nil
+}
+
+// finish ends the stream with a "done" event, or an "error" event when the stream failed for a reason other
+// than the client going away.
+func (e *eventWriter) finish(err error) {
+	if err == nil {
+		_ = e.send("done", struct{}{})
+		return
+	}
+	if e.ctx.Err() != nil {
+		return
+	}
+	code := errcode.Of(err)
+	_ = e.send("error", map[string]interface{}{
+		"error":  err.Error(),
+		"code":   code,
+		"status": errcode.HTTPStatus(code),
+	})
+}
+
+// streamMatchingWords streams pages of matching words as they are read, each preceded by a progress event.
+func (h *Handler) streamMatchingWords(w http.ResponseWriter, r *http.Request, format string, q findwords.Query) {
+	e := newEventWriter(w, r, format)
+	err := q.Stream(maxStreamPages, func(result *findwords.Result, p findwords.Progress) error {
+		if err := e.send("progress", p); err != nil {
+			return err
+		}
+		return e.send("result", result)
+	})
+	e.finish(err)
+}
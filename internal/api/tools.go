@@ -0,0 +1,289 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/abbreviations"
+	"github.com/gotwarlost/crossies/internal/anagrams"
+	"github.com/gotwarlost/crossies/internal/analyse"
+	"github.com/gotwarlost/crossies/internal/charade"
+	"github.com/gotwarlost/crossies/internal/cluedb"
+	"github.com/gotwarlost/crossies/internal/cluelist"
+	"github.com/gotwarlost/crossies/internal/deletion"
+	"github.com/gotwarlost/crossies/internal/doubledef"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/findwords"
+	"github.com/gotwarlost/crossies/internal/fodder"
+	"github.com/gotwarlost/crossies/internal/gridcheck"
+	"github.com/gotwarlost/crossies/internal/hidden"
+	"github.com/gotwarlost/crossies/internal/homophones"
+	"github.com/gotwarlost/crossies/internal/indicators"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/selection"
+	"github.com/gotwarlost/crossies/internal/spoonerism"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/gotwarlost/crossies/internal/wordplay"
+)
+
+// tool is a tool served by the API. Every tool has a /v1/ endpoint and may be used in batches, and tools whose
+// results are a list also have a /v2/ endpoint.
+type tool struct {
+	provider   func(q interface{}) string                   // where the results of the query come from
+	newQuery   func() interface{}                           // returns a pointer to an empty query to decode JSON into
+	fromParams func(values url.Values) (interface{}, error) // returns a pointer to the query from parameters
+	run        func(q interface{}) (interface{}, error)     // runs the query
+	list       func(result interface{}) *listing            // the results for /v2/, nil when they are a report
+	page       func(q interface{}, position, limit int)     // optionally limits the query to a page for /v2/
+	// streams the results of the query as they are found, nil when the tool finds them all at once
+	stream func(h *Handler, w http.ResponseWriter, r *http.Request, format string, q interface{})
+}
+
+// parse returns the query from a JSON body, or else from the parameters and any posted form.
+func (t *tool) parse(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	if isJSON(r) {
+		q := t.newQuery()
+		if err := decodeJSON(w, r, q); err != nil {
+			return nil, err
+		}
+		return q, nil
+	}
+	// clue lists and grids can be long, so queries may be posted as a form as well
+	if err := r.ParseForm(); err != nil {
+		return nil, inputerror.New("invalid form: " + err.Error())
+	}
+	q, err := t.fromParams(r.Form)
+	if err != nil {
+		return nil, errcode.WithDefault(err, errcode.InvalidInput)
+	}
+	return q, nil
+}
+
+// from returns the provider of a tool whose results always come from the same places.
+func from(providers ...string) func(interface{}) string {
+	p := strings.Join(providers, ", ")
+	return func(interface{}) string { return p }
+}
+
+// withWords returns the provider of a tool whose results come from the supplied places and are checked against
+// the word list, which is looked up on thewordfinder.com unless a local list is loaded.
+func withWords(providers ...string) func(interface{}) string {
+	return func(interface{}) string {
+		p := providers
+		if _, remote := wordlist.Default().(wordlist.Remote); remote {
+			p = append(p[:len(p):len(p)], providerWordFinder)
+		}
+		if len(p) == 0 {
+			return providerLocal
+		}
+		return strings.Join(p, ", ")
+	}
+}
+
+// v1 returns the handler of the tool's /v1/ endpoint.
+func (h *Handler) v1(t *tool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, err := t.parse(w, r)
+		if err != nil {
+			h.sendError(w, err)
+			return
+		}
+		if format := streamFormat(r); format != "" {
+			if t.stream == nil {
+				h.sendError(w, inputerror.New("results of this tool cannot be streamed"))
+				return
+			}
+			t.stream(h, w, r, format, q)
+			return
+		}
+		result, err := t.run(q)
+		h.sendResult(w, result, err)
+	}
+}
+
+// tools are the tools served by the API, by the path of their endpoints under /v1/ and /v2/.
+var tools = map[string]*tool{
+	"synonyms": {
+		provider:   from(providerWordHippo),
+		newQuery:   func() interface{} { return &synonyms.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := synonyms.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*synonyms.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*synonyms.Result).Entries}
+		},
+	},
+	"matching-words": {
+		provider:   from(providerWordFinder),
+		newQuery:   func() interface{} { return &findwords.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := findwords.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*findwords.Query).Run() },
+		list: func(result interface{}) *listing {
+			r := result.(*findwords.Result)
+			matches := map[string]bool{}
+			for _, w := range r.SynonymMatches {
+				matches[w] = true
+			}
+			words := make([]*matchingWord, 0, len(r.Words))
+			for _, w := range r.Words {
+				words = append(words, &matchingWord{Word: w, SynonymMatch: matches[w]})
+			}
+			return &listing{items: words, total: r.TotalWords, paged: true, next: r.NextPage}
+		},
+		// pages are those of the site the words come from, so their size cannot be changed
+		page: func(q interface{}, position, limit int) { q.(*findwords.Query).Page = position },
+		stream: func(h *Handler, w http.ResponseWriter, r *http.Request, format string, q interface{}) {
+			h.streamMatchingWords(w, r, format, *q.(*findwords.Query))
+		},
+	},
+	"anagrams": {
+		provider:   from(providerWordFinder),
+		newQuery:   func() interface{} { return &anagrams.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := anagrams.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return anagrams.Solve(*q.(*anagrams.Query)) },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*anagrams.Result).Phrases}
+		},
+	},
+	"fodder": {
+		provider: func(q interface{}) string {
+			// anagrams of the candidates are found on thewordfinder.com
+			if q.(*fodder.Query).Solve {
+				return providerWordFinder
+			}
+			return providerLocal
+		},
+		newQuery:   func() interface{} { return &fodder.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := fodder.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*fodder.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*fodder.Result).Candidates}
+		},
+	},
+	"indicators": {
+		newQuery:   func() interface{} { return &indicators.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := indicators.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*indicators.Query).Run() },
+	},
+	"hidden": {
+		provider:   withWords(),
+		newQuery:   func() interface{} { return &hidden.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := hidden.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*hidden.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*hidden.Result).Entries}
+		},
+	},
+	"abbreviations": {
+		provider:   from(providerLocal),
+		newQuery:   func() interface{} { return &abbreviations.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := abbreviations.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*abbreviations.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*abbreviations.Result).Abbreviations}
+		},
+	},
+	"charades": {
+		provider:   from(providerWordHippo),
+		newQuery:   func() interface{} { return &charade.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := charade.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*charade.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*charade.Result).Entries}
+		},
+	},
+	"homophones": {
+		provider:   from(providerLocal),
+		newQuery:   func() interface{} { return &homophones.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := homophones.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*homophones.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*homophones.Result).Entries}
+		},
+	},
+	"wordplay": {
+		// components of the fragments are synonyms from wordhippo.com
+		provider:   from(providerWordHippo),
+		newQuery:   func() interface{} { return &wordplay.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := wordplay.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*wordplay.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*wordplay.Result).Constructions}
+		},
+	},
+	"deletions": {
+		provider:   withWords(providerWordHippo),
+		newQuery:   func() interface{} { return &deletion.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := deletion.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*deletion.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*deletion.Result).Entries}
+		},
+	},
+	"double-definition": {
+		provider:   from(providerWordHippo),
+		newQuery:   func() interface{} { return &doubledef.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := doubledef.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*doubledef.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*doubledef.Result).Entries}
+		},
+	},
+	"letter-selection": {
+		provider:   withWords(),
+		newQuery:   func() interface{} { return &selection.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := selection.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*selection.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*selection.Result).Entries}
+		},
+	},
+	"analyse": {
+		// definitions are found on wordhippo.com and anagrams of the fodder on thewordfinder.com
+		provider:   from(providerWordHippo, providerWordFinder),
+		newQuery:   func() interface{} { return &analyse.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := analyse.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*analyse.Query).Run() },
+		list: func(result interface{}) *listing {
+			r := result.(*analyse.Result)
+			return &listing{items: r.Candidates, warnings: r.Warnings}
+		},
+	},
+	"spoonerisms": {
+		provider:   withWords(),
+		newQuery:   func() interface{} { return &spoonerism.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := spoonerism.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*spoonerism.Query).Run() },
+		list: func(result interface{}) *listing {
+			return &listing{items: result.(*spoonerism.Result).Entries}
+		},
+	},
+	"parse-clues": {
+		provider:   from(providerLocal),
+		newQuery:   func() interface{} { return &cluelist.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := cluelist.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*cluelist.Query).Run() },
+		list: func(result interface{}) *listing {
+			r := result.(*cluelist.Result)
+			return &listing{items: r.Clues, warnings: r.Warnings}
+		},
+	},
+	"grid/check": {
+		newQuery:   func() interface{} { return &gridcheck.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := gridcheck.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*gridcheck.Query).Run() },
+	},
+	"clues": {
+		provider:   from(providerLocal),
+		newQuery:   func() interface{} { return &cluedb.Query{} },
+		fromParams: func(v url.Values) (interface{}, error) { q, err := cluedb.NewQueryFromParams(v); return &q, err },
+		run:        func(q interface{}) (interface{}, error) { return q.(*cluedb.Query).Run() },
+		list: func(result interface{}) *listing {
+			r := result.(*cluedb.Result)
+			return &listing{items: r.Matches, total: r.Total}
+		},
+		// the page size replaces the limit of the query, so only clues up to the end of the page are needed
+		page: func(q interface{}, position, limit int) { q.(*cluedb.Query).Limit = position + limit },
+	},
+}
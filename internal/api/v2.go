@@ -0,0 +1,222 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+)
+
+// providers of results
+const (
+	providerLocal      = "local" // computed from the word lists and data files of the server
+	providerWordHippo  = "wordhippo.com"
+	providerWordFinder = "thewordfinder.com"
+)
+
+// envelope is the response of every v2 endpoint.
+type envelope struct {
+	Items    interface{} `json:"items"`    // results on this page
+	Total    int         `json:"total"`    // number of results on all pages
+	Cursor   string      `json:"cursor"`   // opaque cursor for the next page, empty on the last page
+	Warnings []string    `json:"warnings"` // problems that did not stop results being returned
+	Provider string      `json:"provider"` // where the results come from, separated by commas if there are several
+	Timing   timing      `json:"timing"`
+}
+
+type timing struct {
+	ElapsedMillis int64 `json:"elapsedMs"`
+}
+
+// cursor is the position of a page of the results of a query.
+type cursor struct {
+	Tool     string `json:"t"`
+	Query    uint32 `json:"q"` // fingerprint of the query, so that cursors are not used with other queries
+	Position int    `json:"p"`
+}
+
+func (c cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func parseCursor(s string) (c cursor, _ error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil || c.Position < 0 {
+		return c, inputerror.New("invalid cursor")
+	}
+	return c, nil
+}
+
+// listRequest is a request for a page of results from a v2 endpoint.
+type listRequest struct {
+	tool        string
+	position    int // position of the page, from the cursor
+	limit       int // number of results on a page
+	from        *cursor
+	fingerprint uint32 // fingerprint of the query
+}
+
+// setQuery sets the fingerprint of the query, and checks that the cursor, if any, belongs to it.
+func (l *listRequest) setQuery(q interface{}) error {
+	b, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(b)
+	l.fingerprint = h.Sum32()
+	if l.from != nil && (l.from.Tool != l.tool || l.from.Query != l.fingerprint) {
+		return inputerror.New("cursor is for a different query")
+	}
+	return nil
+}
+
+// listing is the results of a query. Unless the listing is paged, the items are all the results and the envelope
+// returns the page at the position of the cursor.
+type listing struct {
+	items    interface{} // slice of results
+	total    int         // number of results when there are more than the items
+	warnings []string
+	paged    bool // the items are the page at the position of the cursor
+	next     int  // position of the next page of a paged listing, 0 if there is none
+}
+
+// matchingWord is a word matching a frame, which may also be a synonym of one of the hints.
+type matchingWord struct {
+	Word         string `json:"word"`
+	SynonymMatch bool   `json:"synonymMatch,omitempty"`
+}
+
+// newListRequest returns the request for a v2 tool with the cursor and page size from the "cursor" and "limit"
+// parameters.
+func newListRequest(r *http.Request, tool string) (*listRequest, error) {
+	l := &listRequest{tool: tool, limit: defaultPageSize}
+	values := r.URL.Query()
+	if str := values.Get("cursor"); str != "" {
+		c, err := parseCursor(str)
+		if err != nil {
+			return nil, err
+		}
+		l.from = &c
+		l.position = c.Position
+	}
+	if str := values.Get("limit"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil || n <= 0 {
+			return nil, inputerror.New(fmt.Sprintf("invalid limit %q", str))
+		}
+		l.limit = n
+	}
+	if l.limit > maxPageSize {
+		l.limit = maxPageSize
+	}
+	return l, nil
+}
+
+// page returns the envelope for the page of the listing at the position of the request.
+func (l *listRequest) page(list *listing) *envelope {
+	ret := &envelope{Total: list.total, Warnings: list.warnings}
+	items := reflect.ValueOf(list.items)
+	if !items.IsValid() {
+		items = reflect.ValueOf([]interface{}{})
+	}
+	if ret.Total < items.Len() {
+		ret.Total = items.Len()
+	}
+	next := 0
+	if list.paged {
+		next = list.next
+	} else {
+		start, end := l.position, l.position+l.limit
+		if start > items.Len() {
+			start = items.Len()
+		}
+		if end < ret.Total {
+			next = end
+		}
+		if end > items.Len() {
+			end = items.Len()
+		}
+		items = items.Slice(start, end)
+	}
+	if items.Len() == 0 {
+		// an empty slice rather than null
+		items = reflect.ValueOf([]interface{}{})
+	}
+	ret.Items = items.Interface()
+	if next > 0 {
+		ret.Cursor = cursor{Tool: l.tool, Query: l.fingerprint, Position: next}.String()
+	}
+	if ret.Warnings == nil {
+		ret.Warnings = []string{}
+	}
+	return ret
+}
+
+// v2 serves the tools under /v2/ with results in a common envelope. A search that finds nothing is not an error:
+// the envelope has no items and the reason as a warning. Anything else that is not found, like an upstream page
+// or the page of a stale cursor, is still an error.
+func (h *Handler) v2(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	name := strings.TrimPrefix(r.URL.Path, "/v2/")
+	t, ok := tools[name]
+	if !ok || t.list == nil {
+		h.sendError(w, errcode.Errorf(errcode.NotFound, "unknown endpoint %q", r.URL.Path))
+		return
+	}
+	l, err := newListRequest(r, name)
+	if err != nil {
+		h.sendError(w, err)
+		return
+	}
+	q, err := t.parse(w, r)
+	if err == nil {
+		err = l.setQuery(q)
+	}
+	if err != nil {
+		h.sendError(w, err)
+		return
+	}
+	if t.page != nil {
+		t.page(q, l.position, l.limit)
+	}
+	var list *listing
+	result, err := t.run(q)
+	switch {
+	case err == nil:
+		list = t.list(result)
+	case errcode.IsNoResults(err):
+		list = &listing{warnings: []string{err.Error()}}
+	default:
+		h.sendError(w, err)
+		return
+	}
+	ret := l.page(list)
+	ret.Provider = t.provider(q)
+	ret.Timing.ElapsedMillis = int64(time.Since(start) / time.Millisecond)
+
+	w.Header().Set("Content-Type", "application/json")
+	b, err := json.Marshal(ret)
+	if err != nil {
+		h.sendError(w, err)
+		return
+	}
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,321 @@
+package autofill
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+)
+
+const (
+	defaultTimeLimit = 30 * time.Second
+	allLetters       = 1<<26 - 1
+	deadlineCheck    = 128 // nodes between checks of the time limit
+)
+
+// ErrTimeout is returned when the time limit is reached before a fill is found.
+var ErrTimeout = errcode.New(errcode.Timeout, "time limit reached before a fill was found")
+
+// Options control how a grid is filled.
+type Options struct {
+	MinScore  int           // words scoring less than this are not used
+	TimeLimit time.Duration // time after which to give up, 30 seconds when zero
+}
+
+// Entry is a filled slot.
+type Entry struct {
+	Label  string `json:"label"`
+	Word   string `json:"word"`
+	Score  int    `json:"score"`
+	Preset bool   `json:"preset,omitempty"` // the entry was complete in the supplied grid
+}
+
+// Result is a filled grid.
+type Result struct {
+	Grid    *puzzle.Puzzle `json:"grid"`
+	Entries []*Entry       `json:"entries"`
+	Score   int            `json:"score"` // total score of the entries that were filled
+	Nodes   int            `json:"nodes"` // number of search steps taken
+	Elapsed time.Duration  `json:"elapsed"`
+}
+
+type crossing struct {
+	other    int // index of the crossing slot
+	pos      int // position of the shared square in this slot
+	otherPos int // position of the shared square in the other slot
+}
+
+type slot struct {
+	ps        *puzzle.Slot
+	cells     []int
+	words     []string // candidates of the slot's length, best first
+	domain    []int32  // indexes of the words that still fit
+	word      string   // assigned word, empty while unassigned
+	preset    bool
+	crossings []crossing
+	stamp     int // assignment at which the domain was last saved
+}
+
+// saved is a domain to restore when an assignment is undone.
+type saved struct {
+	s      *slot
+	domain []int32
+}
+
+type filler struct {
+	grid     []byte // letter of every square, 0 when empty
+	slots    []*slot
+	used     map[string]bool
+	deadline time.Time
+	nodes    int
+	timedOut bool
+	stamp    int
+}
+
+// candidates returns the words of each length that score at least the minimum, best first.
+func candidates(list *wordlist.List, length int, minScore int) []string {
+	var ret []string
+	for _, w := range list.WithLength(length) {
+		if list.Score(w) >= minScore {
+			ret = append(ret, w)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		si, sj := list.Score(ret[i]), list.Score(ret[j])
+		if si != sj {
+			return si > sj
+		}
+		return ret[i] < ret[j]
+	})
+	return ret
+}
+
+func bit(ch byte) uint32 {
+	return 1 << (ch - 'a')
+}
+
+// Fill fills the empty squares of the grid with words from the list. Squares with a solution are kept, and
+// slots that are already complete are accepted whether or not they are in the list. No word is used twice.
+func Fill(p *puzzle.Puzzle, list *wordlist.List, opts Options) (*Result, error) {
+	start := time.Now()
+	if opts.TimeLimit <= 0 {
+		opts.TimeLimit = defaultTimeLimit
+	}
+	f := &filler{grid: make([]byte, len(p.Cells)), used: map[string]bool{}, deadline: start.Add(opts.TimeLimit)}
+	for i, c := range p.Cells {
+		if !c.Block && c.Solution != "" {
+			f.grid[i] = strings.ToLower(c.Solution)[0]
+			if f.grid[i] < 'a' || f.grid[i] > 'z' {
+				return nil, inputerror.New(fmt.Sprintf("invalid letter %q in grid", c.Solution))
+			}
+		}
+	}
+
+	byLength := map[int][]string{}
+	atCell := map[int][][2]int{} // slot index and position of the slots through each square
+	for _, ps := range p.Slots() {
+		s := &slot{ps: ps}
+		for _, pos := range ps.Positions() {
+			idx := pos[0]*p.Width + pos[1]
+			atCell[idx] = append(atCell[idx], [2]int{len(f.slots), len(s.cells)})
+			s.cells = append(s.cells, idx)
+		}
+		if _, ok := byLength[ps.Length]; !ok {
+			byLength[ps.Length] = candidates(list, ps.Length, opts.MinScore)
+		}
+		s.words = byLength[ps.Length]
+		f.slots = append(f.slots, s)
+	}
+	for i, s := range f.slots {
+		for pos, idx := range s.cells {
+			for _, other := range atCell[idx] {
+				if other[0] != i {
+					s.crossings = append(s.crossings, crossing{other: other[0], pos: pos, otherPos: other[1]})
+				}
+			}
+		}
+		frame := f.frame(s)
+		if !strings.Contains(frame, ".") {
+			s.word, s.preset = frame, true
+			f.used[frame] = true
+			continue
+		}
+		for wi, w := range s.words {
+			if matches(w, frame) {
+				s.domain = append(s.domain, int32(wi))
+			}
+		}
+		if len(s.domain) == 0 {
+			return nil, inputerror.New(fmt.Sprintf("no words in the list fit %s (%s)", s.ps.Label(), frame))
+		}
+	}
+	if !f.propagate(f.slots, nil) || !f.search() {
+		if f.timedOut {
+			return nil, ErrTimeout
+		}
+		return nil, inputerror.New(fmt.Sprintf("no fill found using words scoring at least %d", opts.MinScore))
+	}
+
+	ret := &Result{Grid: puzzle.New(p.Width, p.Height), Nodes: f.nodes}
+	for i, c := range p.Cells {
+		out := ret.Grid.Cells[i]
+		*out = *c
+		out.Fill = ""
+		if !c.Block && c.Solution == "" {
+			out.Solution = strings.ToUpper(string(f.grid[i]))
+		}
+	}
+	for _, s := range f.slots {
+		e := &Entry{Label: s.ps.Label(), Word: s.word, Score: list.Score(s.word), Preset: s.preset}
+		if !s.preset {
+			ret.Score += e.Score
+		}
+		ret.Entries = append(ret.Entries, e)
+	}
+	ret.Elapsed = time.Since(start)
+	return ret, nil
+}
+
+func matches(word, frame string) bool {
+	for i := 0; i < len(frame); i++ {
+		if frame[i] != '.' && frame[i] != word[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *filler) frame(s *slot) string {
+	b := make([]byte, len(s.cells))
+	for i, idx := range s.cells {
+		b[i] = f.grid[idx]
+		if b[i] == 0 {
+			b[i] = '.'
+		}
+	}
+	return string(b)
+}
+
+// mask returns the letters that a slot could still have at a position.
+func (f *filler) mask(s *slot, pos int) uint32 {
+	if s.word != "" {
+		return bit(s.word[pos])
+	}
+	var m uint32
+	for _, wi := range s.domain {
+		m |= bit(s.words[wi][pos])
+		if m == allLetters {
+			break
+		}
+	}
+	return m
+}
+
+// propagate removes words that no longer fit from the slots crossing the queued slots until nothing changes,
+// saving replaced domains on the trail. It returns false if a slot is left without words.
+func (f *filler) propagate(queue []*slot, trail *[]saved) bool {
+	queue = append([]*slot(nil), queue...)
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, x := range s.crossings {
+			t := f.slots[x.other]
+			if t.word != "" {
+				continue
+			}
+			m := f.mask(s, x.pos)
+			var kept []int32
+			for _, wi := range t.domain {
+				if m&bit(t.words[wi][x.otherPos]) != 0 {
+					kept = append(kept, wi)
+				}
+			}
+			if len(kept) == len(t.domain) {
+				continue
+			}
+			if trail != nil && t.stamp != f.stamp {
+				*trail = append(*trail, saved{s: t, domain: t.domain})
+				t.stamp = f.stamp
+			}
+			t.domain = kept
+			if len(kept) == 0 {
+				return false
+			}
+			queue = append(queue, t)
+		}
+	}
+	return true
+}
+
+// pick returns the unassigned slot with the fewest words left, preferring slots with more unassigned
+// crossings, or nil when every slot is assigned.
+func (f *filler) pick() *slot {
+	var best *slot
+	bestDegree := 0
+	for _, s := range f.slots {
+		if s.word != "" {
+			continue
+		}
+		degree := 0
+		for _, x := range s.crossings {
+			if f.slots[x.other].word == "" {
+				degree++
+			}
+		}
+		if best == nil || len(s.domain) < len(best.domain) || (len(s.domain) == len(best.domain) && degree > bestDegree) {
+			best, bestDegree = s, degree
+		}
+	}
+	return best
+}
+
+func (f *filler) search() bool {
+	f.nodes++
+	if f.nodes%deadlineCheck == 0 && time.Now().After(f.deadline) {
+		f.timedOut = true
+	}
+	if f.timedOut {
+		return false
+	}
+	s := f.pick()
+	if s == nil {
+		return true
+	}
+	for _, wi := range s.domain {
+		w := s.words[wi]
+		if f.used[w] {
+			continue
+		}
+		f.stamp++
+		var trail []saved
+		var filled []int
+		s.word = w
+		f.used[w] = true
+		for i, idx := range s.cells {
+			if f.grid[idx] == 0 {
+				f.grid[idx] = w[i]
+				filled = append(filled, idx)
+			}
+		}
+		if f.propagate([]*slot{s}, &trail) && f.search() {
+			return true
+		}
+		for i := len(trail) - 1; i >= 0; i-- {
+			trail[i].s.domain = trail[i].domain
+		}
+		for _, idx := range filled {
+			f.grid[idx] = 0
+		}
+		delete(f.used, w)
+		s.word = ""
+		if f.timedOut {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package autofill_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/autofill"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const words = `cat;60
+cab;20
+ago;50
+bee;50
+age;50
+toe;50
+tag;40
+ape;50
+are;55
+ore;45
+orb;10
+`
+
+func TestFill(t *testing.T) {
+	list, err := wordlist.Load(strings.NewReader(words))
+	require.NoError(t, err)
+	grid, err := puzzle.ParseGrid(strings.NewReader("c..\n...\n...\n"))
+	require.NoError(t, err)
+
+	res, err := autofill.Fill(grid, list, autofill.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "CAT\nAGO\nBEE\n", puzzle.FormatGrid(res.Grid))
+	assert.Len(t, res.Entries, 6)
+	seen := map[string]bool{}
+	for _, e := range res.Entries {
+		assert.False(t, seen[e.Word], e.Word)
+		seen[e.Word] = true
+	}
+
+	_, err = autofill.Fill(grid, list, autofill.Options{MinScore: 55})
+	require.Error(t, err)
+	assert.True(t, inputerror.IsInputError(err))
+}
+
+func TestFillPresetAndParse(t *testing.T) {
+	list, err := wordlist.Load(strings.NewReader(words))
+	require.NoError(t, err)
+	grid, err := puzzle.ParseGrid(strings.NewReader("; preset first row\ncab\n...\n...\n"))
+	require.NoError(t, err)
+	res, err := autofill.Fill(grid, list, autofill.Options{TimeLimit: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "CAB\nAGE\nTOE\n", puzzle.FormatGrid(res.Grid))
+	assert.True(t, res.Entries[0].Preset)
+
+	grid, err = puzzle.ParseGrid(strings.NewReader("#a.\n...\n..#\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "#A.\n...\n..#\n", puzzle.FormatGrid(grid))
+
+	_, err = puzzle.ParseGrid(strings.NewReader("ab\nabc\n"))
+	assert.True(t, inputerror.IsInputError(err))
+}
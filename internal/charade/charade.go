@@ -0,0 +1,219 @@
+package charade
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/abbreviations"
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+)
+
+const maxResults = 500
+
+// Source is where a component of a charade came from.
+type Source string
+
+// available sources
+const (
+	SourceLiteral      Source = "literal"
+	SourceAbbreviation Source = "abbreviation"
+	SourceSynonym      Source = "synonym"
+)
+
+// Query is a query to build charades, answers made up of components for each part of a clue in order.
+type Query struct {
+	Parts       []string `json:"parts"`                 // clue fragments in the order their components appear
+	Enumeration string   `json:"enumeration,omitempty"` // enumeration of the answer
+	Frame       string   `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	var parts []string
+	for _, p := range q.Parts {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return inputerror.New("no parts specified")
+	}
+	q.Parts = parts
+	var err error
+	q.pattern, err = clue.NewPattern(q.Enumeration, q.Frame)
+	if err != nil {
+		return err
+	}
+	if q.pattern.Length() == 0 {
+		return inputerror.New("no enumeration or frame specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Parts = values["part"]
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Component is the text that a part of the clue contributes to the answer.
+type Component struct {
+	Part   string `json:"part"`   // the clue fragment
+	Text   string `json:"text"`   // letters contributed to the answer
+	Source Source `json:"source"` // how the letters were derived from the fragment
+}
+
+// Entry is a charade that fits the query.
+type Entry struct {
+	Answer     string       `json:"answer"`
+	Derivation string       `json:"derivation"` // components separated by +, for example AB + OUT
+	Components []*Component `json:"components"`
+}
+
+// Result is the result of a query
+type Result struct {
+	Query   *Query   `json:"query,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+type partResult struct {
+	components []*Component
+	err        error
+}
+
+// Components returns the possible components for a part of the clue with at most maxLetters letters:
+// the part itself, its abbreviations and its synonyms.
+func Components(part string, maxLetters int) ([]*Component, error) {
+	seen := map[string]bool{}
+	var ret []*Component
+	add := func(text string, src Source) {
+		text = clue.Letters(text)
+		if text == "" || len(text) > maxLetters || seen[text] {
+			return
+		}
+		seen[text] = true
+		ret = append(ret, &Component{Part: part, Text: text, Source: src})
+	}
+	add(part, SourceLiteral)
+	for _, a := range abbreviations.Default().Lookup(part) {
+		add(a, SourceAbbreviation)
+	}
+	syns, err := synonyms.Default()(part, maxLetters)
+	if err != nil {
+		// a part with no synonyms can still be used literally or abbreviated
+		if errcode.Of(err) == errcode.NotFound {
+			return ret, nil
+		}
+		return nil, err
+	}
+	for _, s := range syns {
+		add(s.Synonym, SourceSynonym)
+	}
+	return ret, nil
+}
+
+// matchPrefix returns true if the supplied letters fit the start of the pattern.
+func (q *Query) matchPrefix(letters string) bool {
+	if len(letters) > q.pattern.Length() {
+		return false
+	}
+	frame := q.pattern.Frame()
+	if frame == "" {
+		return true
+	}
+	for i := 0; i < len(letters); i++ {
+		if frame[i] != '.' && frame[i] != letters[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Run finds synonyms and abbreviations for every part concurrently and combines them in order,
+// returning the combinations that fit the answer.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	results := make([]partResult, len(q.Parts))
+	var wg sync.WaitGroup
+	for i, p := range q.Parts {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			comps, err := Components(p, q.pattern.Length())
+			results[i] = partResult{components: comps, err: err}
+		}(i, p)
+	}
+	wg.Wait()
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+
+	var entries []*Entry
+	var walk func(index int, letters string, chosen []*Component)
+	walk = func(index int, letters string, chosen []*Component) {
+		if index == len(results) {
+			if len(letters) == q.pattern.Length() {
+				entries = append(entries, newEntry(letters, chosen))
+			}
+			return
+		}
+		for _, c := range results[index].components {
+			next := letters + c.Text
+			if !q.matchPrefix(next) {
+				continue
+			}
+			walk(index+1, next, append(chosen, c))
+		}
+	}
+	walk(0, "", nil)
+	if len(entries) == 0 {
+		return nil, errcode.NoResults("no charades found for %s", strings.Join(q.Parts, " + "))
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].score() > entries[j].score()
+	})
+	// truncate only after sorting so that the best charades are kept
+	if len(entries) > maxResults {
+		entries = entries[:maxResults]
+	}
+	return &Result{Query: q, Entries: entries}, nil
+}
+
+func newEntry(answer string, chosen []*Component) *Entry {
+	comps := make([]*Component, len(chosen))
+	copy(comps, chosen)
+	var parts []string
+	for _, c := range comps {
+		parts = append(parts, strings.ToUpper(c.Text))
+	}
+	return &Entry{
+		Answer:     answer,
+		Derivation: strings.Join(parts, " + "),
+		Components: comps,
+	}
+}
+
+// score prefers charades made of synonyms and abbreviations over ones using clue words literally.
+func (e *Entry) score() int {
+	s := 0
+	for _, c := range e.Components {
+		if c.Source != SourceLiteral {
+			s++
+		}
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package charade_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/charade"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/synonyms/synonymstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSynonyms is a synonym source with a fixed set of synonyms that fails with err when it is set.
+func testSynonyms(err error) synonyms.Source {
+	source := synonymstest.Static(map[string][]string{
+		"automobile": {"car", "auto"},
+		"animal":     {"pet", "dog"},
+	})
+	return func(word string, maxLetters int) ([]*synonyms.Entry, error) {
+		if err != nil {
+			return nil, err
+		}
+		return source(word, maxLetters)
+	}
+}
+
+func TestMain(m *testing.M) {
+	synonyms.SetDefault(testSynonyms(nil))
+	os.Exit(m.Run())
+}
+
+func answers(r *charade.Result) []string {
+	var ret []string
+	for _, e := range r.Entries {
+		ret = append(ret, e.Answer)
+	}
+	return ret
+}
+
+func TestCharade(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   charade.Query
+		answers []string
+		first   string
+	}{
+		{
+			name:    "synonyms",
+			query:   charade.Query{Parts: []string{"automobile", "animal"}, Enumeration: "6"},
+			answers: []string{"carpet", "cardog"},
+		},
+		{
+			name:    "frame",
+			query:   charade.Query{Parts: []string{"automobile", "animal"}, Frame: "....e."},
+			answers: []string{"carpet"},
+		},
+		{
+			// "y" has neither synonyms nor abbreviations, so it can only be used as it is
+			name:    "abbreviation and literal",
+			query:   charade.Query{Parts: []string{"doctor", " y "}, Frame: "d.."},
+			answers: []string{"dry"},
+			first:   "DR + Y",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := test.query.Run()
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.answers, answers(res))
+			if test.first != "" {
+				assert.Equal(t, test.first, res.Entries[0].Derivation)
+			}
+		})
+	}
+}
+
+func TestCharadeTruncation(t *testing.T) {
+	// every part can be used literally or as a synonym, making 512 charades with the all synonym one last
+	syns := map[string][]string{}
+	var parts, derivation []string
+	for c := 'b'; c <= 'j'; c++ {
+		part := "q" + string(c)
+		syns[part] = []string{"z" + string(c)}
+		parts = append(parts, part)
+		derivation = append(derivation, "Z"+strings.ToUpper(string(c)))
+	}
+	synonyms.SetDefault(synonymstest.Static(syns))
+	defer synonyms.SetDefault(testSynonyms(nil))
+
+	q := charade.Query{Parts: parts, Enumeration: "18"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.Len(t, res.Entries, 500)
+	assert.Equal(t, strings.Join(derivation, " + "), res.Entries[0].Derivation)
+}
+
+func TestCharadeErrors(t *testing.T) {
+	q := charade.Query{Parts: []string{"automobile", "animal"}, Enumeration: "8"}
+	_, err := q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	// failures other than finding no synonyms are not hidden
+	q = charade.Query{Parts: []string{"doctor", "y"}, Enumeration: "3"}
+	synonyms.SetDefault(testSynonyms(errcode.New(errcode.UpstreamUnavailable, "down")))
+	_, err = q.Run()
+	synonyms.SetDefault(testSynonyms(nil))
+	assert.Equal(t, errcode.UpstreamUnavailable, errcode.Of(err))
+
+	for _, q := range []charade.Query{{Parts: []string{" "}, Enumeration: "3"}, {Parts: []string{"a"}}} {
+		_, err = q.Run()
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err))
+	}
+}
@@ -0,0 +1,195 @@
+package clue
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+)
+
+var (
+	enumerationRE = regexp.MustCompile(`^\s*\(?\s*(\d+(?:\s*[-,\s]\s*\d+)*)\s*\)?\s*$`)
+	trailingRE    = regexp.MustCompile(`\s*\(\s*(\d+(?:\s*[-,\s]\s*\d+)*)\s*\)\s*$`)
+	partRE        = regexp.MustCompile(`\d+|[-,]`)
+)
+
+// Word is a single word of clue text.
+type Word struct {
+	Text    string `json:"text"`    // word as it appears in the clue without surrounding punctuation
+	Letters string `json:"letters"` // lower case letters of the word
+}
+
+// Letters returns the lower case letters in the supplied text, ignoring everything else.
+func Letters(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Split splits clue text into words, dropping surrounding punctuation and words without letters.
+func Split(text string) []Word {
+	var ret []Word
+	for _, f := range strings.Fields(text) {
+		t := strings.TrimFunc(f, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		letters := Letters(t)
+		if letters == "" {
+			continue
+		}
+		ret = append(ret, Word{Text: t, Letters: letters})
+	}
+	return ret
+}
+
+// Join returns the text of the supplied words separated by spaces.
+func Join(words []Word) string {
+	var parts []string
+	for _, w := range words {
+		parts = append(parts, w.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Enumeration is the word lengths of an answer, such as (3,4) or (5-3).
+type Enumeration struct {
+	Parts      []int    `json:"parts"`                // length of each word
+	Separators []string `json:"separators,omitempty"` // separator following each word except the last, "," or "-"
+}
+
+// ParseEnumeration parses enumerations like "9", "(3,4)", "5-3" and "3 4".
+func ParseEnumeration(s string) (Enumeration, error) {
+	var e Enumeration
+	m := enumerationRE.FindStringSubmatch(s)
+	if m == nil {
+		return e, inputerror.New(fmt.Sprintf("invalid enumeration %q", s))
+	}
+	sep := ","
+	for _, tok := range partRE.FindAllString(m[1], -1) {
+		if tok == "-" || tok == "," {
+			sep = tok
+			continue
+		}
+		n, _ := strconv.Atoi(tok)
+		if n == 0 {
+			return e, inputerror.New(fmt.Sprintf("invalid enumeration %q", s))
+		}
+		if len(e.Parts) > 0 {
+			e.Separators = append(e.Separators, sep)
+		}
+		e.Parts = append(e.Parts, n)
+		sep = ","
+	}
+	return e, nil
+}
+
+// SplitEnumeration removes a trailing enumeration from clue text, returning the clue text, the enumeration
+// and whether an enumeration was found.
+func SplitEnumeration(text string) (string, Enumeration, bool) {
+	loc := trailingRE.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return strings.TrimSpace(text), Enumeration{}, false
+	}
+	e, err := ParseEnumeration(text[loc[2]:loc[3]])
+	if err != nil {
+		return strings.TrimSpace(text), Enumeration{}, false
+	}
+	return strings.TrimSpace(text[:loc[0]]), e, true
+}
+
+// Length returns the total number of letters in the enumeration.
+func (e Enumeration) Length() int {
+	n := 0
+	for _, p := range e.Parts {
+		n += p
+	}
+	return n
+}
+
+// String returns the enumeration in its usual form, for example (3,4).
+func (e Enumeration) String() string {
+	var b strings.Builder
+	b.WriteString("(")
+	for i, p := range e.Parts {
+		if i > 0 {
+			b.WriteString(e.Separators[i-1])
+		}
+		b.WriteString(strconv.Itoa(p))
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// Pattern is the length and frame that an answer must fit.
+type Pattern struct {
+	length int
+	frame  string
+}
+
+// NewPattern returns a pattern for the supplied enumeration and frame. Either may be empty; when both are
+// supplied their lengths must agree. Frames consist of letters with a '.' for each unknown letter and may
+// contain spaces or hyphens between words.
+func NewPattern(enumeration string, frame string) (*Pattern, error) {
+	p := &Pattern{}
+	if enumeration != "" {
+		e, err := ParseEnumeration(enumeration)
+		if err != nil {
+			return nil, err
+		}
+		p.length = e.Length()
+	}
+	if frame != "" {
+		var b strings.Builder
+		for _, r := range strings.ToLower(frame) {
+			switch {
+			case r >= 'a' && r <= 'z', r == '.':
+				b.WriteRune(r)
+			case r == ' ', r == '-', r == ',':
+			default:
+				return nil, inputerror.New("frame can only contain letters or dots")
+			}
+		}
+		p.frame = b.String()
+		if p.length > 0 && p.length != len(p.frame) {
+			return nil, inputerror.New(fmt.Sprintf("frame %q does not match enumeration %s", frame, enumeration))
+		}
+		p.length = len(p.frame)
+	}
+	return p, nil
+}
+
+// Length returns the number of letters in the pattern, 0 if unconstrained.
+func (p *Pattern) Length() int {
+	return p.length
+}
+
+// Frame returns the normalized frame, which may be empty.
+func (p *Pattern) Frame() string {
+	return p.frame
+}
+
+// Known returns the letters in the frame that are known.
+func (p *Pattern) Known() string {
+	return strings.ReplaceAll(p.frame, ".", "")
+}
+
+// Match returns true if the letters of the supplied word or phrase fit the pattern.
+func (p *Pattern) Match(word string) bool {
+	letters := Letters(word)
+	if p.length > 0 && len(letters) != p.length {
+		return false
+	}
+	for i := 0; i < len(p.frame); i++ {
+		if p.frame[i] != '.' && p.frame[i] != letters[i] {
+			return false
+		}
+	}
+	return true
+}
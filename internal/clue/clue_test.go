@@ -0,0 +1,56 @@
+package clue_test
+
+import (
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitEnumeration(t *testing.T) {
+	text, e, ok := clue.SplitEnumeration("Mad teens fly around in parts of the world (9)")
+	require.True(t, ok)
+	assert.Equal(t, "Mad teens fly around in parts of the world", text)
+	assert.Equal(t, 9, e.Length())
+
+	_, e, ok = clue.SplitEnumeration("Some clue (5-3)")
+	require.True(t, ok)
+	assert.Equal(t, []int{5, 3}, e.Parts)
+	assert.Equal(t, "(5-3)", e.String())
+
+	_, _, ok = clue.SplitEnumeration("No enumeration here")
+	assert.False(t, ok)
+}
+
+func TestParseEnumeration(t *testing.T) {
+	e, err := clue.ParseEnumeration("3,4")
+	require.NoError(t, err)
+	assert.Equal(t, 7, e.Length())
+	assert.Equal(t, "(3,4)", e.String())
+
+	_, err = clue.ParseEnumeration("three")
+	require.Error(t, err)
+	assert.True(t, inputerror.IsInputError(err))
+}
+
+func TestSplit(t *testing.T) {
+	words := clue.Split(`"Mad" teens, fly - around!`)
+	require.Len(t, words, 4)
+	assert.Equal(t, "Mad", words[0].Text)
+	assert.Equal(t, "mad", words[0].Letters)
+	assert.Equal(t, "around", words[3].Letters)
+}
+
+func TestPattern(t *testing.T) {
+	p, err := clue.NewPattern("3,4", "a.. b...")
+	require.NoError(t, err)
+	assert.Equal(t, 7, p.Length())
+	assert.True(t, p.Match("ant bear"))
+	assert.False(t, p.Match("ant wasp"))
+	assert.False(t, p.Match("ants bear"))
+
+	_, err = clue.NewPattern("5", "a..")
+	assert.Error(t, err)
+}
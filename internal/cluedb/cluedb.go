@@ -0,0 +1,337 @@
+// Package cluedb is a local database of past clues and their answers, searchable by clue text, answer
+// pattern and answer.
+package cluedb
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/pkg/errors"
+)
+
+// version of the file format, files with a different version must be imported again
+const formatVersion = 1
+
+// Entry is a clue and its answer.
+type Entry struct {
+	Clue        string `json:"clue"`
+	Enumeration string `json:"enumeration,omitempty"`
+	Answer      string `json:"answer"`
+	Source      string `json:"source,omitempty"` // where the clue came from, such as the puzzle title
+}
+
+// file is the saved form of a database. The index is saved along with the entries so that opening a large
+// database does not have to rebuild it.
+type file struct {
+	Version int
+	Entries []Entry
+	Terms   map[string][]int32 // entries containing each stemmed word of clue text, in order
+}
+
+// DB is a clue database held in memory and saved to a single file.
+type DB struct {
+	path     string
+	l        sync.RWMutex
+	entries  []Entry
+	terms    map[string][]int32
+	answers  map[string][]int32 // entries for the letters of each answer
+	byLength map[int][]int32    // entries for each answer length
+	seen     map[string]bool    // clue and answer pairs already present
+}
+
+func newDB(path string) *DB {
+	return &DB{
+		path:     path,
+		terms:    map[string][]int32{},
+		answers:  map[string][]int32{},
+		byLength: map[int][]int32{},
+		seen:     map[string]bool{},
+	}
+}
+
+func pairKey(e *Entry) string {
+	return strings.Join(strings.Fields(strings.ToLower(e.Clue)), " ") + "\t" + clue.Letters(e.Answer)
+}
+
+// Open opens the database saved in the supplied file.
+func Open(path string) (*DB, error) {
+	return open(path, false)
+}
+
+// OpenOrCreate is the same as Open but returns an empty database if the file does not exist. The file is
+// created when the database is saved.
+func OpenOrCreate(path string) (*DB, error) {
+	return open(path, true)
+}
+
+func open(path string, create bool) (*DB, error) {
+	db := newDB(path)
+	f, err := os.Open(path)
+	if err != nil {
+		if create && os.IsNotExist(err) {
+			return db, nil
+		}
+		return nil, errors.Wrap(err, "open clue database")
+	}
+	defer func() { _ = f.Close() }()
+	var saved file
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&saved); err != nil {
+		return nil, errors.Wrapf(err, "read clue database %s", path)
+	}
+	if saved.Version != formatVersion {
+		return nil, fmt.Errorf("clue database %s has format version %d, expected %d", path, saved.Version, formatVersion)
+	}
+	db.entries = saved.Entries
+	if saved.Terms != nil {
+		db.terms = saved.Terms
+	}
+	for i := range db.entries {
+		db.indexAnswer(int32(i))
+	}
+	return db, nil
+}
+
+// Save writes the database to its file, replacing the previous contents atomically.
+func (db *DB) Save() error {
+	db.l.RLock()
+	defer db.l.RUnlock()
+	if db.path == "" {
+		return fmt.Errorf("clue database has no file")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "save clue database")
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	w := bufio.NewWriter(tmp)
+	err = gob.NewEncoder(w).Encode(file{Version: formatVersion, Entries: db.entries, Terms: db.terms})
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "save clue database")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), db.path), "save clue database")
+}
+
+func (db *DB) indexAnswer(id int32) {
+	e := &db.entries[id]
+	letters := clue.Letters(e.Answer)
+	db.answers[letters] = append(db.answers[letters], id)
+	db.byLength[len(letters)] = append(db.byLength[len(letters)], id)
+	db.seen[pairKey(e)] = true
+}
+
+// Len returns the number of entries in the database.
+func (db *DB) Len() int {
+	db.l.RLock()
+	defer db.l.RUnlock()
+	return len(db.entries)
+}
+
+// Add adds an entry, returning false if it has no clue or answer or is already present. An enumeration at the
+// end of the clue text is moved to the enumeration of the entry.
+func (db *DB) Add(e Entry) bool {
+	db.l.Lock()
+	defer db.l.Unlock()
+	return db.add(e)
+}
+
+func (db *DB) add(e Entry) bool {
+	text, enum, ok := clue.SplitEnumeration(e.Clue)
+	e.Clue = text
+	if ok && e.Enumeration == "" {
+		e.Enumeration = strings.Trim(enum.String(), "()")
+	}
+	e.Answer = strings.ToUpper(strings.TrimSpace(e.Answer))
+	if e.Clue == "" || clue.Letters(e.Answer) == "" || db.seen[pairKey(&e)] {
+		return false
+	}
+	id := int32(len(db.entries))
+	db.entries = append(db.entries, e)
+	for _, t := range terms(e.Clue) {
+		db.terms[t] = append(db.terms[t], id)
+	}
+	db.indexAnswer(id)
+	return true
+}
+
+// ImportTSV adds entries from tab separated lines with the clue, the answer and optionally the source, which
+// defaults to the supplied one. Blank lines, lines starting with # and a header line are ignored. It returns
+// the number of entries added.
+func (db *DB) ImportTSV(r io.Reader, source string) (int, error) {
+	db.l.Lock()
+	defer db.l.Unlock()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	added, line := 0, 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 2 {
+			return added, inputerror.New(fmt.Sprintf("line %d: expected a clue and answer separated by a tab", line))
+		}
+		if line == 1 && strings.EqualFold(fields[0], "clue") && strings.EqualFold(fields[1], "answer") {
+			continue
+		}
+		e := Entry{Clue: strings.TrimSpace(fields[0]), Answer: fields[1], Source: source}
+		if len(fields) > 2 && strings.TrimSpace(fields[2]) != "" {
+			e.Source = strings.TrimSpace(fields[2])
+		}
+		if db.add(e) {
+			added++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return added, errors.Wrap(err, "read clues")
+	}
+	return added, nil
+}
+
+// ImportPuzzle adds the clues of a puzzle whose answers are complete, returning the number of entries added.
+func (db *DB) ImportPuzzle(p *puzzle.Puzzle, source string) int {
+	db.l.Lock()
+	defer db.l.Unlock()
+	added := 0
+	for _, s := range p.Slots() {
+		if s.Clue == nil {
+			continue
+		}
+		answer := p.Answer(s)
+		if strings.Contains(answer, ".") {
+			continue
+		}
+		e := Entry{Clue: s.Clue.Text, Enumeration: s.Clue.Enumeration, Answer: answer, Source: source}
+		if db.add(e) {
+			added++
+		}
+	}
+	return added
+}
+
+// Filter selects entries. Every supplied condition must hold.
+type Filter struct {
+	Text    string        // words in the clue text, matching other forms of the same words
+	Pattern *clue.Pattern // answer length and known letters
+	Answer  string        // exact answer, ignoring case, spaces and punctuation
+	Limit   int           // maximum number of matches to return, all when zero
+}
+
+// Match is an entry matching a filter.
+type Match struct {
+	Entry
+	Score float64 `json:"score,omitempty"` // relevance to the filter text, higher is better
+}
+
+// Find returns the entries matching a filter, best first, and the total number of matching entries.
+// Matches for text are ranked by how many of the words they contain, rarer words counting for more.
+func (db *DB) Find(f Filter) ([]*Match, int) {
+	db.l.RLock()
+	defer db.l.RUnlock()
+	var candidates []int32
+	all := true // candidates is every entry
+	if f.Answer != "" {
+		candidates, all = db.answers[clue.Letters(f.Answer)], false
+	} else if f.Pattern != nil && f.Pattern.Length() > 0 {
+		candidates, all = db.byLength[f.Pattern.Length()], false
+	}
+
+	scores := map[int32]float64{}
+	queryTerms := terms(f.Text)
+	if len(queryTerms) > 0 {
+		var allowed map[int32]bool
+		if !all {
+			allowed = map[int32]bool{}
+			for _, id := range candidates {
+				allowed[id] = true
+			}
+		}
+		for _, t := range queryTerms {
+			postings := db.terms[t]
+			idf := math.Log(1 + float64(len(db.entries))/float64(1+len(postings)))
+			for _, id := range postings {
+				if allowed == nil || allowed[id] {
+					scores[id] += idf
+				}
+			}
+		}
+		candidates, all = candidates[:0:0], false
+		for id := range scores {
+			candidates = append(candidates, id)
+		}
+	} else if strings.TrimSpace(f.Text) != "" {
+		return nil, 0 // only stop words
+	}
+
+	var ret []*Match
+	consider := func(id int32) {
+		e := db.entries[id]
+		if f.Pattern != nil && !f.Pattern.Match(e.Answer) {
+			return
+		}
+		ret = append(ret, &Match{Entry: e, Score: math.Round(scores[id]*1000) / 1000})
+	}
+	if all {
+		for i := range db.entries {
+			consider(int32(i))
+		}
+	} else {
+		for _, id := range candidates {
+			consider(id)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+		if len(ret[i].Clue) != len(ret[j].Clue) {
+			return len(ret[i].Clue) < len(ret[j].Clue)
+		}
+		if ret[i].Clue != ret[j].Clue {
+			return ret[i].Clue < ret[j].Clue
+		}
+		return ret[i].Answer < ret[j].Answer
+	})
+	total := len(ret)
+	if f.Limit > 0 && len(ret) > f.Limit {
+		ret = ret[:f.Limit]
+	}
+	return ret, total
+}
+
+var (
+	defaultLock sync.RWMutex
+	defaultDB   *DB
+)
+
+// Default returns the database used by queries, nil if none has been set.
+func Default() *DB {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultDB
+}
+
+// SetDefault sets the database used by queries.
+func SetDefault(db *DB) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultDB = db
+}
@@ -0,0 +1,95 @@
+package cluedb_test
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/cluedb"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tsv = `clue	answer	source
+Baking dish for a pie (3)	tin
+Baked in an oven	ROASTED	Times 1
+Can baked beans	TIN
+# comment
+Can baked beans	TIN
+Ran quickly, having dashed off	SPED
+`
+
+func answers(matches []*cluedb.Match) []string {
+	var ret []string
+	for _, m := range matches {
+		ret = append(ret, m.Answer)
+	}
+	return ret
+}
+
+func TestImportAndFind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clues.db")
+	_, err := cluedb.Open(path)
+	assert.Error(t, err, "only created when asked to")
+	db, err := cluedb.OpenOrCreate(path)
+	require.NoError(t, err)
+	n, err := db.ImportTSV(strings.NewReader(tsv), "test")
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	p, err := puzzle.ParseGrid(strings.NewReader("CAT\n#.#\n"))
+	require.NoError(t, err)
+	p.Clues = []*puzzle.Clue{{Number: 1, Direction: puzzle.Across, Text: "Pet that bakes in the sun"}}
+	assert.Equal(t, 1, db.ImportPuzzle(p, "grid"))
+	require.NoError(t, db.Save())
+
+	db, err = cluedb.Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, db.Len())
+
+	matches, total := db.Find(cluedb.Filter{Text: "bake"})
+	assert.Equal(t, 4, total)
+	assert.ElementsMatch(t, []string{"TIN", "ROASTED", "TIN", "CAT"}, answers(matches))
+
+	matches, _ = db.Find(cluedb.Filter{Text: "baked pie"})
+	assert.Equal(t, "Baking dish for a pie", matches[0].Clue)
+	assert.Equal(t, "3", matches[0].Enumeration)
+	assert.Equal(t, "test", matches[0].Source)
+
+	matches, _ = db.Find(cluedb.Filter{Text: "run dash"})
+	assert.Equal(t, []string{"SPED"}, answers(matches))
+
+	pattern, err := clue.NewPattern("", "r.....d")
+	require.NoError(t, err)
+	matches, _ = db.Find(cluedb.Filter{Pattern: pattern})
+	assert.Equal(t, []string{"ROASTED"}, answers(matches))
+
+	matches, total = db.Find(cluedb.Filter{Answer: "tin", Limit: 1})
+	assert.Equal(t, 2, total)
+	assert.Len(t, matches, 1)
+}
+
+func TestQuery(t *testing.T) {
+	_, err := cluedb.NewQueryFromParams(url.Values{})
+	assert.True(t, inputerror.IsInputError(err))
+	_, err = cluedb.NewQueryFromParams(url.Values{"frame": {"c?t"}})
+	assert.True(t, inputerror.IsInputError(err))
+
+	db, err := cluedb.OpenOrCreate(filepath.Join(t.TempDir(), "clues.db"))
+	require.NoError(t, err)
+	_, err = db.ImportTSV(strings.NewReader(tsv), "")
+	require.NoError(t, err)
+	cluedb.SetDefault(db)
+	defer cluedb.SetDefault(nil)
+
+	q, err := cluedb.NewQueryFromParams(url.Values{"text": {"beans"}, "enumeration": {"3"}})
+	require.NoError(t, err)
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Total)
+	assert.Equal(t, "Can baked beans", res.Matches[0].Clue)
+}
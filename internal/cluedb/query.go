@@ -0,0 +1,83 @@
+package cluedb
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+)
+
+const defaultLimit = 50
+
+// Query is a query to find past clues.
+type Query struct {
+	Text        string `json:"text,omitempty"`        // words to find in clue text
+	Answer      string `json:"answer,omitempty"`      // answer to find other clues for
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with dots for unknown ones
+	Enumeration string `json:"enumeration,omitempty"` // lengths of the answer words
+	Limit       int    `json:"limit,omitempty"`       // maximum number of clues, 50 if not specified
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	if q.Text == "" && q.Answer == "" && q.Frame == "" && q.Enumeration == "" {
+		return inputerror.New("one of text, answer, frame or enumeration is needed")
+	}
+	if q.Limit < 0 {
+		return inputerror.New(fmt.Sprintf("invalid limit %d", q.Limit))
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultLimit
+	}
+	p, err := clue.NewPattern(q.Enumeration, q.Frame)
+	if err != nil {
+		return err
+	}
+	q.pattern = p
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Text = values.Get("text")
+	q.Answer = values.Get("answer")
+	q.Frame = values.Get("frame")
+	q.Enumeration = values.Get("enumeration")
+	if str := values.Get("limit"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return q, inputerror.New(fmt.Sprintf("invalid limit %q", str))
+		}
+		q.Limit = n
+	}
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Result is the result of a clue query.
+type Result struct {
+	Query   *Query   `json:"query,omitempty"`
+	Matches []*Match `json:"matches"`
+	Total   int      `json:"total"` // number of matching clues, which may be more than were returned
+}
+
+// Run finds clues in the default database.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	db := Default()
+	if db == nil {
+		return nil, errcode.New(errcode.NotConfigured, "no clue database is configured")
+	}
+	matches, total := db.Find(Filter{Text: q.Text, Pattern: q.pattern, Answer: q.Answer, Limit: q.Limit})
+	if matches == nil {
+		matches = []*Match{}
+	}
+	return &Result{Query: q, Matches: matches, Total: total}, nil
+}
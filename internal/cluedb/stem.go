@@ -0,0 +1,71 @@
+package cluedb
+
+import (
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+)
+
+// words too common to be worth indexing
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true, "for": true, "from": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiou", b) >= 0
+}
+
+func hasVowel(s string) bool {
+	return strings.IndexAny(s, "aeiouy") >= 0
+}
+
+// stem reduces a lower case word to a stem shared by its common inflections, so that "baking", "baked" and
+// "bakes" all become "bak". Stems are only used for matching and are not always words.
+func stem(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+	switch {
+	case strings.HasSuffix(w, "ies"), strings.HasSuffix(w, "ied"):
+		w = w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"), strings.HasSuffix(w, "us"), strings.HasSuffix(w, "is"):
+	case strings.HasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+	for _, suffix := range []string{"ing", "ed", "ly"} {
+		base := strings.TrimSuffix(w, suffix)
+		if base == w || len(base) < 3 || !hasVowel(base) {
+			continue
+		}
+		w = base
+		// undo doubling as in "running" or "stopped"
+		if n := len(w); suffix != "ly" && w[n-1] == w[n-2] && !isVowel(w[n-1]) && strings.IndexByte("lsz", w[n-1]) < 0 {
+			w = w[:n-1]
+		}
+		break
+	}
+	if len(w) > 3 && strings.HasSuffix(w, "e") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// terms returns the distinct stems of the words in clue text, leaving out stop words.
+func terms(text string) []string {
+	var ret []string
+	seen := map[string]bool{}
+	for _, w := range clue.Split(text) {
+		if stopWords[w.Letters] {
+			continue
+		}
+		t := stem(w.Letters)
+		if !seen[t] {
+			seen[t] = true
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
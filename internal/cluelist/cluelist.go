@@ -0,0 +1,190 @@
+package cluelist
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+)
+
+// ref matches a clue number with an optional direction, either attached ("12a") or as a word ("12 Down").
+const ref = `\d+(?:\s*(?:across|down|ac|dn)\b\.?|[ad]\b\.?)?`
+
+var (
+	headingRE = regexp.MustCompile(`(?i)^(across|down|ac|dn)(?:\s+clues)?\s*:?$`)
+	clueRE    = regexp.MustCompile(`(?i)^(` + ref + `(?:\s*[,/&]\s*` + ref + `)*)\s*[.:)]?(?:\s+(.*))?$`)
+	refRE     = regexp.MustCompile(`(?i)(\d+)\s*([a-z]*)`)
+)
+
+// Clue is a single clue from a clue list.
+type Clue struct {
+	Number      int               `json:"number"`
+	Direction   puzzle.Direction  `json:"direction,omitempty"` // empty when the list does not say
+	Label       string            `json:"label"`               // for example 12A, or just 12 without a direction
+	Linked      []string          `json:"linked,omitempty"`    // labels of further slots for a clue spanning several
+	Text        string            `json:"text"`                // clue text without the enumeration
+	Enumeration *clue.Enumeration `json:"enumeration,omitempty"`
+	Length      int               `json:"length,omitempty"` // total letters from the enumeration
+	Frame       string            `json:"frame,omitempty"`  // all dots frame of the answer length, for find-words
+	Line        int               `json:"line"`             // line on which the clue starts
+}
+
+func parseDirection(s string) (puzzle.Direction, bool) {
+	switch strings.TrimSuffix(strings.ToLower(s), ".") {
+	case "a", "ac", "across":
+		return puzzle.Across, true
+	case "d", "dn", "down":
+		return puzzle.Down, true
+	}
+	return "", false
+}
+
+func label(number int, dir puzzle.Direction) string {
+	if dir == "" {
+		return strconv.Itoa(number)
+	}
+	return fmt.Sprintf("%d%s", number, dir.Short())
+}
+
+// parseRefs returns the numbers and directions in a reference list such as "1, 15 Down".
+func parseRefs(s string, heading puzzle.Direction) ([]int, []puzzle.Direction) {
+	var numbers []int
+	var dirs []puzzle.Direction
+	for _, m := range refRE.FindAllStringSubmatch(s, -1) {
+		n, _ := strconv.Atoi(m[1])
+		dir, ok := parseDirection(m[2])
+		if !ok {
+			dir = heading
+			if len(dirs) > 0 {
+				dir = dirs[0]
+			}
+		}
+		numbers = append(numbers, n)
+		dirs = append(dirs, dir)
+	}
+	return numbers, dirs
+}
+
+func (c *Clue) finish() {
+	text, e, ok := clue.SplitEnumeration(c.Text)
+	c.Text = text
+	if ok {
+		c.Enumeration = &e
+		c.Length = e.Length()
+		c.Frame = strings.Repeat(".", c.Length)
+	}
+}
+
+// Parse parses free-form clue list text into clues. Lines with just "Across" or "Down" set the direction of the
+// clues that follow, clue lines start with a number optionally followed by a direction, and lines that do not
+// start with a number continue the previous clue. Lines that cannot be used are reported as warnings.
+func Parse(text string) ([]*Clue, []string) {
+	var clues []*Clue
+	var warnings []string
+	var heading puzzle.Direction
+	var current *Clue
+	flush := func() {
+		if current != nil {
+			current.finish()
+			clues = append(clues, current)
+			current = nil
+		}
+	}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(nil, len(text)+1)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.Join(strings.Fields(scanner.Text()), " ")
+		if line == "" {
+			continue
+		}
+		if m := headingRE.FindStringSubmatch(line); m != nil {
+			flush()
+			heading, _ = parseDirection(m[1])
+			continue
+		}
+		if m := clueRE.FindStringSubmatch(line); m != nil {
+			flush()
+			numbers, dirs := parseRefs(m[1], heading)
+			current = &Clue{Number: numbers[0], Direction: dirs[0], Label: label(numbers[0], dirs[0]), Text: m[2], Line: lineNo}
+			for i := 1; i < len(numbers); i++ {
+				current.Linked = append(current.Linked, label(numbers[i], dirs[i]))
+			}
+			if current.Direction == "" {
+				warnings = append(warnings, fmt.Sprintf("line %d: no direction for clue %d", lineNo, current.Number))
+			}
+			continue
+		}
+		if current != nil {
+			if _, _, complete := clue.SplitEnumeration(current.Text); !complete {
+				// a clue wrapped over several lines
+				current.Text = strings.TrimSpace(current.Text + " " + line)
+				continue
+			}
+		}
+		warnings = append(warnings, fmt.Sprintf("line %d: ignored %q", lineNo, line))
+	}
+	flush()
+	return clues, warnings
+}
+
+// Puzzle returns a puzzle with the supplied clues and no grid, so that a clue list can be used where a puzzle
+// is expected, for example to print it.
+func Puzzle(clues []*Clue) *puzzle.Puzzle {
+	p := puzzle.New(0, 0)
+	for _, c := range clues {
+		pc := &puzzle.Clue{Number: c.Number, Direction: c.Direction, Text: c.Text}
+		if c.Enumeration != nil {
+			pc.Enumeration = strings.Trim(c.Enumeration.String(), "()")
+		}
+		p.Clues = append(p.Clues, pc)
+	}
+	return p
+}
+
+// Query is a query to parse a clue list.
+type Query struct {
+	Text string `json:"text"`
+}
+
+func (q *Query) initialize() error {
+	if strings.TrimSpace(q.Text) == "" {
+		return inputerror.New("no clue text specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Text = values.Get("text")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Result is the result of parsing a clue list.
+type Result struct {
+	Query    *Query   `json:"query,omitempty"`
+	Clues    []*Clue  `json:"clues"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Run parses the clue list in the query.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	clues, warnings := Parse(q.Text)
+	if len(clues) == 0 {
+		return nil, inputerror.New("no numbered clues found in text")
+	}
+	return &Result{Query: q, Clues: clues, Warnings: warnings}, nil
+}
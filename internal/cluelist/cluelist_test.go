@@ -0,0 +1,68 @@
+package cluelist_test
+
+import (
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/cluelist"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `The Daily Cryptic
+
+ACROSS
+1 Tear apart cat (4)
+5. Crumb, small, around
+   the bread (5-3)
+9,14 See 14 Down (3,4)
+12 Across Mad teens fly around (9)
+Solution tomorrow
+
+Down:
+2 A bit of fish on toast (3)
+3d Plain speaking (5,2,3)
+14
+Bring it back (5)
+`
+
+func TestParse(t *testing.T) {
+	clues, warnings := cluelist.Parse(sample)
+	assert.Equal(t, []string{`line 1: ignored "The Daily Cryptic"`, `line 9: ignored "Solution tomorrow"`}, warnings)
+	require.Len(t, clues, 7)
+
+	var labels []string
+	for _, c := range clues {
+		labels = append(labels, c.Label)
+	}
+	assert.Equal(t, []string{"1A", "5A", "9A", "12A", "2D", "3D", "14D"}, labels)
+
+	c := clues[1]
+	assert.Equal(t, "Crumb, small, around the bread", c.Text)
+	assert.Equal(t, "(5-3)", c.Enumeration.String())
+	assert.Equal(t, "........", c.Frame)
+	assert.Equal(t, 5, c.Line)
+
+	assert.Equal(t, []string{"14A"}, clues[2].Linked)
+	assert.Equal(t, "Mad teens fly around", clues[3].Text)
+	assert.Equal(t, 9, clues[3].Length)
+	assert.Equal(t, "A bit of fish on toast", clues[4].Text)
+	assert.Equal(t, puzzle.Down, clues[5].Direction)
+	assert.Equal(t, 10, clues[5].Length)
+	assert.Equal(t, "Bring it back", clues[6].Text)
+}
+
+func TestParseWithoutDirection(t *testing.T) {
+	clues, warnings := cluelist.Parse("7 Listen to the sea (4)")
+	require.Len(t, clues, 1)
+	assert.Equal(t, "7", clues[0].Label)
+	assert.Len(t, warnings, 1)
+}
+
+func TestPuzzle(t *testing.T) {
+	clues, _ := cluelist.Parse(sample)
+	p := cluelist.Puzzle(clues)
+	assert.Zero(t, p.Width)
+	require.Len(t, p.Clues, len(clues))
+	assert.Equal(t, &puzzle.Clue{Number: 5, Direction: puzzle.Across, Text: "Crumb, small, around the bread", Enumeration: "5-3"}, p.Clues[1])
+}
@@ -0,0 +1,284 @@
+package collab
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/session"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultPollInterval = 500 * time.Millisecond
+	messageSync         = "sync"
+	messageError        = "error"
+)
+
+// Hub coordinates solvers sharing a session. Every change to the grid is saved to the session store, so
+// solvers connected to different processes see each other's changes. Solvers connected to the same process
+// are told about changes immediately, and about changes made elsewhere within the poll interval. Cursors
+// are also kept in the store, so the same goes for them.
+type Hub struct {
+	store        *session.Store
+	pollInterval time.Duration
+	l            sync.Mutex
+	subs         map[string]map[chan struct{}]bool
+}
+
+// NewHub returns a hub for sessions in the supplied store.
+func NewHub(store *session.Store) *Hub {
+	return &Hub{
+		store:        store,
+		pollInterval: defaultPollInterval,
+		subs:         map[string]map[chan struct{}]bool{},
+	}
+}
+
+// Apply applies operations to a session in order and returns the events for the changes to the grid.
+// Either all operations are applied or none are. Cursor operations are not saved with the session and have
+// no events.
+func (h *Hub) Apply(id string, ops ...session.Op) ([]*session.Event, error) {
+	var edits, moves []session.Op
+	for _, op := range ops {
+		if op.Type == session.OpCursor || op.Type == session.OpLeave {
+			moves = append(moves, op)
+		} else {
+			edits = append(edits, op)
+		}
+	}
+	var events []*session.Event
+	cursors := map[string]*session.Cursor{}
+	apply := func(s *session.Session) error {
+		for _, op := range moves {
+			if op.Type == session.OpLeave {
+				cursors[op.Client] = nil
+				continue
+			}
+			c, err := s.Cursor(op)
+			if err != nil {
+				return err
+			}
+			cursors[op.Client] = c
+		}
+		events = nil
+		for _, op := range edits {
+			e, err := s.Apply(op)
+			if err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+		return nil
+	}
+	var err error
+	if len(edits) > 0 {
+		_, err = h.store.Update(id, apply)
+	} else {
+		var s *session.Session
+		if s, err = h.store.Get(id); err == nil {
+			err = apply(s)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cursors) > 0 {
+		if err := h.store.MoveCursors(id, cursors); err != nil {
+			return nil, err
+		}
+	}
+	h.Notify(id)
+	return events, nil
+}
+
+// sameCursors returns true if two sets of cursors are at the same positions and were last moved at the same time.
+func sameCursors(a, b map[string]*session.Cursor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for client, c := range a {
+		o := b[client]
+		if o == nil || o.Row != c.Row || o.Col != c.Col || o.Direction != c.Direction || !o.Seen.Equal(c.Seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Notify tells solvers of a session connected to this process that it has changed.
+func (h *Hub) Notify(id string) {
+	h.l.Lock()
+	defer h.l.Unlock()
+	for ch := range h.subs[id] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *Hub) subscribe(id string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.l.Lock()
+	defer h.l.Unlock()
+	if h.subs[id] == nil {
+		h.subs[id] = map[chan struct{}]bool{}
+	}
+	h.subs[id][ch] = true
+	return ch, func() {
+		h.l.Lock()
+		defer h.l.Unlock()
+		delete(h.subs[id], ch)
+		if len(h.subs[id]) == 0 {
+			delete(h.subs, id)
+		}
+	}
+}
+
+// Sync returns what a solver who has seen events up to the supplied sequence needs to catch up.
+func (h *Hub) Sync(id string, since int64) (*session.Sync, error) {
+	s, err := h.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := s.SyncSince(since)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Cursors, err = h.store.Cursors(id); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Wait is like Sync but waits for a change when there is none, until the context is done. This is the
+// long polling alternative to a WebSocket for servers that cannot hold connections open, such as FastCGI.
+func (h *Hub) Wait(ctx context.Context, id string, since int64) (*session.Sync, error) {
+	ch, cancel := h.subscribe(id)
+	defer cancel()
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+	notified := false
+	var cursors map[string]*session.Cursor
+	for first := true; ; first = false {
+		s, err := h.Sync(id, since)
+		if err != nil {
+			return nil, err
+		}
+		// a sequence behind the solver's means the session was replaced, so they also need the result,
+		// and a change without a new sequence is a cursor move
+		if s.Seq != since || notified || !first && !sameCursors(cursors, s.Cursors) {
+			return s, nil
+		}
+		cursors = s.Cursors
+		select {
+		case <-ctx.Done():
+			return s, nil
+		case <-ch:
+			notified = true
+		case <-ticker.C:
+		}
+	}
+}
+
+// message is sent by a solver over a WebSocket. It is either an operation or a request to resync from a
+// sequence, which is how a solver that detects a gap or reconnects catches up.
+type message struct {
+	session.Op
+	Since int64 `json:"since,omitempty"`
+}
+
+// reply is sent to a solver over a WebSocket.
+type reply struct {
+	Type string `json:"type"`
+	*session.Sync
+	Error string `json:"error,omitempty"`
+}
+
+// WebSocket returns a handler for a solver sharing a session over a WebSocket. The solver first receives
+// the changes after the supplied sequence, or a snapshot when it is negative, then every later change.
+// Operations sent by the solver are applied as if made by the named client.
+func (h *Hub) WebSocket(id string, client string, since int64) http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		defer func() { _ = ws.Close() }()
+		h.serve(ws, id, client, since)
+	})
+}
+
+func (h *Hub) serve(ws *websocket.Conn, id string, client string, since int64) {
+	changed, cancel := h.subscribe(id)
+	defer cancel()
+	resync := make(chan int64, 1)
+	errs := make(chan string, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for {
+			var m message
+			if err := websocket.JSON.Receive(ws, &m); err != nil {
+				return
+			}
+			if m.Type == messageSync {
+				select {
+				case <-resync:
+				default:
+				}
+				resync <- m.Since
+				continue
+			}
+			m.Op.Client = client
+			if _, err := h.Apply(id, m.Op); err != nil {
+				select {
+				case errs <- err.Error():
+				default:
+				}
+			}
+		}
+	}()
+	defer func() { _, _ = h.Apply(id, session.Op{Type: session.OpLeave, Client: client}) }()
+
+	seq := since
+	var cursors map[string]*session.Cursor
+	send := func(force bool) bool {
+		s, err := h.Sync(id, seq)
+		if err != nil {
+			_ = websocket.JSON.Send(ws, reply{Type: messageError, Error: err.Error()})
+			return false
+		}
+		if !force && s.Seq == seq && sameCursors(cursors, s.Cursors) {
+			return true
+		}
+		if err := websocket.JSON.Send(ws, reply{Type: messageSync, Sync: s}); err != nil {
+			return false
+		}
+		seq, cursors = s.Seq, s.Cursors
+		return true
+	}
+	if !send(true) {
+		return
+	}
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+	for {
+		ok := true
+		select {
+		case <-done:
+			return
+		case from := <-resync:
+			seq = from
+			ok = send(true)
+		case msg := <-errs:
+			ok = websocket.JSON.Send(ws, reply{Type: messageError, Error: msg}) == nil
+		case <-changed:
+			ok = send(true) // cursor moves do not change the sequence
+		case <-ticker.C:
+			ok = send(false)
+		}
+		if !ok {
+			return
+		}
+	}
+}
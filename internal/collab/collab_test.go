@@ -0,0 +1,153 @@
+package collab_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/collab"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+type reply struct {
+	Type string `json:"type"`
+	session.Sync
+	Error string `json:"error"`
+}
+
+func setup(t *testing.T) (*collab.Hub, *session.Store, string) {
+	store, err := session.NewStore(t.TempDir(), time.Hour, 0)
+	require.NoError(t, err)
+	sess, err := store.Create(session.NewFromPuzzle(puzzle.New(3, 3)))
+	require.NoError(t, err)
+	return collab.NewHub(store), store, sess.ID
+}
+
+func connect(t *testing.T, hub *collab.Hub, id, client string, since int64) *websocket.Conn {
+	srv := httptest.NewServer(hub.WebSocket(id, client, since))
+	t.Cleanup(srv.Close)
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), "", srv.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws
+}
+
+func receive(t *testing.T, ws *websocket.Conn) reply {
+	var r reply
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, websocket.JSON.Receive(ws, &r))
+	return r
+}
+
+func TestWebSocket(t *testing.T) {
+	hub, _, id := setup(t)
+	alice := connect(t, hub, id, "alice", -1)
+	bob := connect(t, hub, id, "bob", -1)
+	assert.NotNil(t, receive(t, alice).Snapshot)
+	assert.NotNil(t, receive(t, bob).Snapshot)
+
+	require.NoError(t, websocket.JSON.Send(alice, session.Op{Type: session.OpCell, Row: 1, Col: 2, Value: "x", Pencil: true}))
+	r := receive(t, bob)
+	require.Len(t, r.Events, 1)
+	e := r.Events[0]
+	assert.Equal(t, "alice", e.Client)
+	assert.Equal(t, "X", e.Value)
+	assert.True(t, e.Pencil)
+
+	// bob overwrites without having seen the change, which is applied but flagged
+	require.NoError(t, websocket.JSON.Send(bob, session.Op{Type: session.OpCell, Row: 1, Col: 2, Value: "y"}))
+	for r = receive(t, alice); len(r.Events) == 0 || r.Events[len(r.Events)-1].Client != "bob"; r = receive(t, alice) {
+	}
+	assert.True(t, r.Events[len(r.Events)-1].Conflict)
+
+	require.NoError(t, websocket.JSON.Send(bob, map[string]interface{}{"type": "sync", "since": 0}))
+	for r = receive(t, bob); r.Snapshot == nil && len(r.Events) < 2; r = receive(t, bob) {
+	}
+	assert.Equal(t, int64(1), r.Events[0].Seq)
+}
+
+func TestWait(t *testing.T) {
+	hub, _, id := setup(t)
+	s, err := hub.Sync(id, -1)
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = hub.Apply(id, session.Op{Type: session.OpCell, Client: "carol", Row: 0, Col: 0, Value: "a"})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err = hub.Wait(ctx, id, s.Seq)
+	require.NoError(t, err)
+	require.Len(t, s.Events, 1)
+	assert.Equal(t, "A", s.Events[0].Value)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s, err = hub.Wait(ctx, id, s.Seq)
+	require.NoError(t, err)
+	assert.Empty(t, s.Events)
+}
+
+func TestCursorsNotSaved(t *testing.T) {
+	hub, store, id := setup(t)
+	before, err := store.Get(id)
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = hub.Apply(id, session.Op{Type: session.OpCursor, Client: "carol", Row: 1, Col: 2, Direction: puzzle.Down})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := hub.Wait(ctx, id, 0)
+	require.NoError(t, err)
+	assert.Empty(t, s.Events)
+	require.Contains(t, s.Cursors, "carol")
+	assert.Equal(t, 2, s.Cursors["carol"].Col)
+	assert.Equal(t, puzzle.Down, s.Cursors["carol"].Direction)
+
+	after, err := store.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, before.Updated, after.Updated, "cursor moves do not rewrite the session")
+	assert.Equal(t, before.Room, after.Room)
+
+	_, err = hub.Apply(id, session.Op{Type: session.OpLeave, Client: "carol"})
+	require.NoError(t, err)
+	s, err = hub.Sync(id, 0)
+	require.NoError(t, err)
+	assert.Empty(t, s.Cursors)
+
+	_, err = hub.Apply(id, session.Op{Type: session.OpCursor, Client: "carol", Row: 5, Col: 5})
+	assert.Error(t, err)
+}
+
+func TestCursorsShared(t *testing.T) {
+	// hubs in different processes share only the store
+	hub, store, id := setup(t)
+	other := collab.NewHub(store)
+
+	s, err := other.Sync(id, -1)
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = hub.Apply(id, session.Op{Type: session.OpCursor, Client: "carol", Row: 1, Col: 2})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err = other.Wait(ctx, id, s.Seq)
+	require.NoError(t, err)
+	require.Contains(t, s.Cursors, "carol")
+	assert.Equal(t, 2, s.Cursors["carol"].Col)
+
+	_, err = hub.Apply(id, session.Op{Type: session.OpLeave, Client: "carol"})
+	require.NoError(t, err)
+	s, err = other.Sync(id, s.Seq)
+	require.NoError(t, err)
+	assert.Empty(t, s.Cursors)
+}
@@ -0,0 +1,73 @@
+package datafiles
+
+import (
+	"github.com/gotwarlost/crossies/internal/abbreviations"
+	"github.com/gotwarlost/crossies/internal/cluedb"
+	"github.com/gotwarlost/crossies/internal/homophones"
+	"github.com/gotwarlost/crossies/internal/indicators"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/spf13/cobra"
+)
+
+// Files are optional data files that extend or replace the built-in data used by the tools.
+type Files struct {
+	Indicators    string // additional cryptic indicators
+	Abbreviations string // additional abbreviations
+	Dictionary    string // word list to check candidate words against instead of looking them up online
+	CMUDict       string // pronouncing dictionary in the CMU format
+	ClueDB        string // database of past clues
+	CreateClueDB  bool   // create the clue database if it does not exist, when importing clues into it
+}
+
+// AddFlags adds flags for the data files to the supplied command, as persistent flags when requested.
+func (f *Files) AddFlags(cmd *cobra.Command, persistent bool) {
+	flags := cmd.Flags()
+	if persistent {
+		flags = cmd.PersistentFlags()
+	}
+	flags.StringVar(&f.Indicators, "indicators", "", "file with additional cryptic indicators")
+	flags.StringVar(&f.Abbreviations, "abbreviations", "", "file with additional abbreviations")
+	flags.StringVar(&f.Dictionary, "dictionary", "", "word list used to check candidate words instead of looking them up online")
+	flags.StringVar(&f.CMUDict, "cmudict", "", "pronouncing dictionary in the CMU format, used to find homophones")
+	flags.StringVar(&f.ClueDB, "clue-db", "", "database of past clues, created by importing clues into it")
+}
+
+// Load loads the data files that have been specified.
+func (f *Files) Load() error {
+	if f.Indicators != "" {
+		if err := indicators.LoadFile(f.Indicators); err != nil {
+			return err
+		}
+	}
+	if f.Abbreviations != "" {
+		if err := abbreviations.LoadFile(f.Abbreviations); err != nil {
+			return err
+		}
+	}
+	if f.Dictionary != "" {
+		list, err := wordlist.LoadFile(f.Dictionary)
+		if err != nil {
+			return err
+		}
+		wordlist.SetDefault(list)
+	}
+	if f.CMUDict != "" {
+		d, err := homophones.LoadFile(f.CMUDict)
+		if err != nil {
+			return err
+		}
+		homophones.SetDefault(d)
+	}
+	if f.ClueDB != "" {
+		open := cluedb.Open
+		if f.CreateClueDB {
+			open = cluedb.OpenOrCreate
+		}
+		db, err := open(f.ClueDB)
+		if err != nil {
+			return err
+		}
+		cluedb.SetDefault(db)
+	}
+	return nil
+}
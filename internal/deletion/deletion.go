@@ -0,0 +1,167 @@
+package deletion
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+)
+
+// Type is the kind of deletion.
+type Type string
+
+// available deletion types
+const (
+	TypeFirst   Type = "first"   // beheadment, "headless"
+	TypeLast    Type = "last"    // curtailment, "endless"
+	TypeMiddle  Type = "middle"  // "heartless", the middle letter or two
+	TypeEnds    Type = "ends"    // first and last letters, "shelled"
+	TypeLetters Type = "letters" // specified letters, "without X"
+)
+
+var allTypes = map[Type]bool{
+	TypeFirst:   true,
+	TypeLast:    true,
+	TypeMiddle:  true,
+	TypeEnds:    true,
+	TypeLetters: true,
+}
+
+// Query is a query to find answers formed by deleting letters from synonyms of a definition.
+type Query struct {
+	Word        string `json:"word"`                  // definition word or phrase whose synonyms are used
+	Type        Type   `json:"type"`                  // the kind of deletion
+	Letters     string `json:"letters,omitempty"`     // letters to remove for the letters deletion type
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the answer
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	if clue.Letters(q.Word) == "" {
+		return inputerror.New("no word specified")
+	}
+	if q.Type == "" {
+		q.Type = TypeFirst
+	}
+	if !allTypes[q.Type] {
+		return inputerror.New(fmt.Sprintf("invalid deletion type %q", q.Type))
+	}
+	q.Letters = clue.Letters(q.Letters)
+	if q.Type == TypeLetters && q.Letters == "" {
+		return inputerror.New("no letters specified for deletion")
+	}
+	var err error
+	q.pattern, err = clue.NewPattern(q.Enumeration, q.Frame)
+	return err
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Word = values.Get("word")
+	q.Type = Type(values.Get("type"))
+	q.Letters = values.Get("letters")
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Entry is an answer formed by a deletion.
+type Entry struct {
+	Answer  string `json:"answer"`
+	Synonym string `json:"synonym"` // the synonym that letters were removed from
+	Removed string `json:"removed"` // the letters that were removed
+}
+
+// Result is the result of a query
+type Result struct {
+	Query   *Query   `json:"query,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+// apply returns the results of applying the deletion to the supplied letters.
+func (q *Query) apply(letters string) []*Entry {
+	n := len(letters)
+	if n < 2 {
+		return nil
+	}
+	switch q.Type {
+	case TypeFirst:
+		return []*Entry{{Answer: letters[1:], Removed: letters[:1]}}
+	case TypeLast:
+		return []*Entry{{Answer: letters[:n-1], Removed: letters[n-1:]}}
+	case TypeEnds:
+		if n < 3 {
+			return nil
+		}
+		return []*Entry{{Answer: letters[1 : n-1], Removed: letters[:1] + letters[n-1:]}}
+	case TypeMiddle:
+		if n < 3 {
+			return nil
+		}
+		start, end := n/2, n/2+1
+		if n%2 == 0 {
+			start = n/2 - 1
+		}
+		return []*Entry{{Answer: letters[:start] + letters[end:], Removed: letters[start:end]}}
+	default:
+		var ret []*Entry
+		for pos := 0; pos+len(q.Letters) <= n; pos++ {
+			if letters[pos:pos+len(q.Letters)] == q.Letters && len(q.Letters) < n {
+				ret = append(ret, &Entry{Answer: letters[:pos] + letters[pos+len(q.Letters):], Removed: q.Letters})
+			}
+		}
+		return ret
+	}
+}
+
+// Run finds synonyms of the query word, applies the deletion and returns the results that are real
+// words fitting the enumeration and frame.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	syns, err := synonyms.Default()(q.Word, 0)
+	if err != nil {
+		return nil, err
+	}
+	var cands []*Entry
+	seen := map[string]bool{}
+	var words []string
+	for _, e := range syns {
+		for _, c := range q.apply(clue.Letters(e.Synonym)) {
+			if !q.pattern.Match(c.Answer) || seen[c.Answer+"|"+e.Synonym] {
+				continue
+			}
+			seen[c.Answer+"|"+e.Synonym] = true
+			c.Synonym = e.Synonym
+			cands = append(cands, c)
+			words = append(words, c.Answer)
+		}
+	}
+	found, err := wordlist.Default().Words(words)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, c := range cands {
+		if found[c.Answer] {
+			entries = append(entries, c)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errcode.NoResults("no %s deletions of synonyms of %q found", q.Type, q.Word)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Answer < entries[j].Answer
+	})
+	return &Result{Query: q, Entries: entries}, nil
+}
@@ -0,0 +1,106 @@
+package deletion_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/deletion"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/synonyms/synonymstest"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSynonyms = synonymstest.Static(map[string][]string{
+		"animal": {"beast", "goat", "cattle", "horse", "pigs", "swine"},
+	})
+	testWords = wordlist.New([]string{"east", "oat", "pig", "best", "hose", "win", "wine", "beat"})
+)
+
+func TestMain(m *testing.M) {
+	synonyms.SetDefault(testSynonyms)
+	wordlist.SetDefault(testWords)
+	os.Exit(m.Run())
+}
+
+func TestDeletion(t *testing.T) {
+	type entry struct {
+		answer, synonym, removed string
+	}
+	tests := []struct {
+		query   deletion.Query
+		entries []entry
+	}{
+		{
+			query:   deletion.Query{Word: "Animal"},
+			entries: []entry{{"east", "beast", "b"}, {"oat", "goat", "g"}, {"wine", "swine", "s"}},
+		},
+		{
+			query:   deletion.Query{Word: "animal", Type: deletion.TypeLast},
+			entries: []entry{{"pig", "pigs", "s"}},
+		},
+		{
+			query:   deletion.Query{Word: "animal", Type: deletion.TypeMiddle},
+			entries: []entry{{"best", "beast", "a"}, {"hose", "horse", "r"}},
+		},
+		{
+			query:   deletion.Query{Word: "animal", Type: deletion.TypeEnds},
+			entries: []entry{{"win", "swine", "se"}},
+		},
+		{
+			query:   deletion.Query{Word: "animal", Type: deletion.TypeLetters, Letters: "S"},
+			entries: []entry{{"beat", "beast", "s"}, {"pig", "pigs", "s"}, {"wine", "swine", "s"}},
+		},
+		{
+			query:   deletion.Query{Word: "animal", Enumeration: "3"},
+			entries: []entry{{"oat", "goat", "g"}},
+		},
+		{
+			query:   deletion.Query{Word: "animal", Frame: "e..."},
+			entries: []entry{{"east", "beast", "b"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.query.Type)+test.query.Enumeration+test.query.Frame, func(t *testing.T) {
+			res, err := test.query.Run()
+			require.NoError(t, err)
+			var got []entry
+			for _, e := range res.Entries {
+				got = append(got, entry{e.Answer, e.Synonym, e.Removed})
+			}
+			assert.Equal(t, test.entries, got)
+		})
+	}
+}
+
+func TestDeletionErrors(t *testing.T) {
+	q := deletion.Query{Word: "animal", Type: deletion.TypeLast, Enumeration: "5"}
+	_, err := q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	q = deletion.Query{Word: "vegetable"}
+	_, err = q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	failing := func(string, int) ([]*synonyms.Entry, error) {
+		return nil, errcode.New(errcode.UpstreamUnavailable, "down")
+	}
+	q = deletion.Query{Word: "animal"}
+	synonyms.SetDefault(failing)
+	_, err = q.Run()
+	synonyms.SetDefault(testSynonyms)
+	assert.Equal(t, errcode.UpstreamUnavailable, errcode.Of(err))
+
+	for _, q := range []deletion.Query{
+		{},
+		{Word: "animal", Type: "sideways"},
+		{Word: "animal", Type: deletion.TypeLetters},
+		{Word: "animal", Enumeration: "x"},
+	} {
+		_, err = q.Run()
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err), "%+v", q)
+	}
+}
@@ -0,0 +1,168 @@
+package doubledef
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+)
+
+const maxRunningLookups = 4 // maximum synonym lookups run at once
+
+// Query is a query to solve a clue made up of two definitions side by side.
+type Query struct {
+	Clue        string `json:"clue"`                  // clue text, optionally ending with an enumeration like (5)
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the answer when not part of the clue
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	words       []clue.Word
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	text, e, ok := clue.SplitEnumeration(q.Clue)
+	enum := q.Enumeration
+	if enum == "" && ok {
+		enum = e.String()
+	}
+	q.words = clue.Split(text)
+	if len(q.words) < 2 {
+		return inputerror.New("clue must have at least two words")
+	}
+	var err error
+	q.pattern, err = clue.NewPattern(enum, q.Frame)
+	if err != nil {
+		return err
+	}
+	if q.pattern.Length() == 0 {
+		return inputerror.New("no enumeration or frame specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Clue = values.Get("clue")
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Entry is an answer that is a synonym of both halves of the clue.
+type Entry struct {
+	Answer   string `json:"answer"`
+	Left     string `json:"left"`     // first definition
+	Right    string `json:"right"`    // second definition
+	Priority int    `json:"priority"` // combined priority of the synonym for both definitions, lower is better
+}
+
+// Result is the result of a query
+type Result struct {
+	Query   *Query   `json:"query,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+type synonymResult struct {
+	entries map[string]int // normalized synonym to priority
+	err     error
+}
+
+func (q *Query) synonyms(phrase string, source synonyms.Source) synonymResult {
+	syns, err := source(phrase, 0)
+	if err != nil {
+		return synonymResult{err: err}
+	}
+	ret := map[string]int{}
+	for _, e := range syns {
+		if !q.pattern.Match(e.Synonym) {
+			continue
+		}
+		key := strings.ToLower(e.Synonym)
+		if p, ok := ret[key]; !ok || e.Priority < p {
+			ret[key] = e.Priority
+		}
+	}
+	return synonymResult{entries: ret}
+}
+
+// Run tries every split point of the clue, finds synonyms for the halves a few at a time and returns
+// the synonyms common to both halves that fit the answer, best first.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	source := synonyms.Default()
+	phrases := map[string]bool{}
+	for i := 1; i < len(q.words); i++ {
+		phrases[clue.Join(q.words[:i])] = true
+		phrases[clue.Join(q.words[i:])] = true
+	}
+	queue := make(chan string, len(phrases))
+	for p := range phrases {
+		queue <- p
+	}
+	close(queue)
+	workers := maxRunningLookups
+	if len(phrases) < workers {
+		workers = len(phrases)
+	}
+	var l sync.Mutex
+	var wg sync.WaitGroup
+	results := map[string]synonymResult{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for phrase := range queue {
+				r := q.synonyms(phrase, source)
+				l.Lock()
+				results[phrase] = r
+				l.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	best := map[string]*Entry{}
+	for i := 1; i < len(q.words); i++ {
+		left, right := clue.Join(q.words[:i]), clue.Join(q.words[i:])
+		lr, rr := results[left], results[right]
+		// a half without synonyms just cannot be a definition
+		for _, err := range []error{lr.err, rr.err} {
+			if err != nil && !errcode.IsNoResults(err) {
+				return nil, err
+			}
+		}
+		for syn, lp := range lr.entries {
+			rp, ok := rr.entries[syn]
+			if !ok {
+				continue
+			}
+			if e, ok := best[syn]; ok && e.Priority <= lp+rp {
+				continue
+			}
+			best[syn] = &Entry{Answer: syn, Left: left, Right: right, Priority: lp + rp}
+		}
+	}
+	if len(best) == 0 {
+		return nil, errcode.NoResults("no synonyms common to both parts of the clue were found")
+	}
+	entries := make([]*Entry, 0, len(best))
+	for _, e := range best {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Priority != entries[j].Priority {
+			return entries[i].Priority < entries[j].Priority
+		}
+		return entries[i].Answer < entries[j].Answer
+	})
+	return &Result{Query: q, Entries: entries}, nil
+}
@@ -0,0 +1,98 @@
+package doubledef_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/doubledef"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/synonyms/synonymstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSynonyms = synonymstest.Static(map[string][]string{
+	"reasonable": {"fair", "Just", "sane"},
+	"fun fair":   {"fete", "fair", "just"},
+	"fair":       {"just", "blonde"},
+})
+
+// failOn returns a synonym source that fails for the supplied phrase.
+func failOn(phrase string) synonyms.Source {
+	return func(word string, maxLetters int) ([]*synonyms.Entry, error) {
+		if word == phrase {
+			return nil, errcode.New(errcode.UpstreamUnavailable, "down")
+		}
+		return testSynonyms(word, maxLetters)
+	}
+}
+
+func TestMain(m *testing.M) {
+	synonyms.SetDefault(testSynonyms)
+	os.Exit(m.Run())
+}
+
+func TestDoubleDefinition(t *testing.T) {
+	type entry struct {
+		answer, left, right string
+		priority            int
+	}
+	tests := []struct {
+		name    string
+		query   doubledef.Query
+		source  synonyms.Source
+		entries []entry
+	}{
+		{
+			name:    "best first",
+			query:   doubledef.Query{Clue: "Reasonable fun fair (4)"},
+			source:  testSynonyms,
+			entries: []entry{{"fair", "Reasonable", "fun fair", 3}, {"just", "Reasonable", "fun fair", 5}},
+		},
+		{
+			name:    "frame",
+			query:   doubledef.Query{Clue: "Reasonable fun fair", Enumeration: "4", Frame: "j..."},
+			source:  testSynonyms,
+			entries: []entry{{"just", "Reasonable", "fun fair", 5}},
+		},
+		{
+			// "reasonable fun" has no synonyms
+			name:    "halves without synonyms ignored",
+			query:   doubledef.Query{Clue: "Reasonable fun fair (4)", Frame: "f..."},
+			source:  testSynonyms,
+			entries: []entry{{"fair", "Reasonable", "fun fair", 3}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			synonyms.SetDefault(test.source)
+			defer synonyms.SetDefault(testSynonyms)
+			res, err := test.query.Run()
+			require.NoError(t, err)
+			var got []entry
+			for _, e := range res.Entries {
+				got = append(got, entry{e.Answer, e.Left, e.Right, e.Priority})
+			}
+			assert.Equal(t, test.entries, got)
+		})
+	}
+}
+
+func TestDoubleDefinitionErrors(t *testing.T) {
+	q := doubledef.Query{Clue: "Reasonable fun fair (5)"}
+	_, err := q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	// failures other than finding no synonyms are reported even when other halves match
+	q = doubledef.Query{Clue: "Reasonable fun fair (4)"}
+	synonyms.SetDefault(failOn("Reasonable fun"))
+	_, err = q.Run()
+	synonyms.SetDefault(testSynonyms)
+	assert.Equal(t, errcode.UpstreamUnavailable, errcode.Of(err))
+
+	for _, q := range []doubledef.Query{{Clue: "Reasonable (4)"}, {Clue: "Reasonable fun fair"}} {
+		_, err = q.Run()
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err), q.Clue)
+	}
+}
@@ -0,0 +1,129 @@
+// Package errcode classifies errors with codes so that every tool reports problems the same way.
+package errcode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Code is the kind of an error. Codes are stable and may be relied on by API clients.
+type Code string
+
+// error codes
+const (
+	InvalidInput          Code = "invalid_input"           // the request is malformed or cannot be satisfied as made
+	NotFound              Code = "not_found"               // the requested thing does not exist or nothing matched
+	UpstreamUnavailable   Code = "upstream_unavailable"    // a site that results come from could not be reached
+	UpstreamFormatChanged Code = "upstream_format_changed" // a site that results come from returned something unexpected
+	Timeout               Code = "timeout"                 // the work did not finish in time
+	MethodNotAllowed      Code = "method_not_allowed"      // the HTTP method is not supported by the endpoint
+	NotConfigured         Code = "not_configured"          // a data file that the tool needs has not been configured
+	Internal              Code = "internal"                // anything else
+)
+
+type codedError struct {
+	code      Code
+	msg       string
+	cause     error
+	noResults bool
+}
+
+func (e *codedError) Error() string {
+	switch {
+	case e.cause == nil:
+		return e.msg
+	case e.msg == "":
+		return e.cause.Error()
+	}
+	return e.msg + ": " + e.cause.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.cause
+}
+
+// New returns an error with the supplied code and message.
+func New(code Code, msg string) error {
+	return &codedError{code: code, msg: msg}
+}
+
+// Errorf returns an error with the supplied code and a formatted message.
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &codedError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// NoResults returns a NotFound error for a search that ran and found nothing, as opposed to one that failed because
+// something it needed was missing.
+func NoResults(format string, args ...interface{}) error {
+	return &codedError{code: NotFound, msg: fmt.Sprintf(format, args...), noResults: true}
+}
+
+// IsNoResults returns true if the outermost code of an error is from a search that found nothing.
+func IsNoResults(err error) bool {
+	var ce *codedError
+	return errors.As(err, &ce) && ce.noResults
+}
+
+// Wrap returns an error with the supplied code that adds a message to an existing error, nil if it is nil.
+func Wrap(err error, code Code, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, msg: msg, cause: err}
+}
+
+// WithCode returns the error with the supplied code and an unchanged message, nil if it is nil.
+func WithCode(err error, code Code) error {
+	return Wrap(err, code, "")
+}
+
+// WithDefault returns the error with the supplied code unless it already has one.
+func WithDefault(err error, code Code) error {
+	if err == nil || Of(err) != Internal {
+		return err
+	}
+	return WithCode(err, code)
+}
+
+// Of returns the code of an error, which is the outermost code in its chain of causes. Errors without a
+// code are timeouts if they come from a context deadline or network timeout, and internal otherwise.
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var ce *codedError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return Timeout
+	}
+	return Internal
+}
+
+// HTTPStatus returns the HTTP status for a code.
+func HTTPStatus(code Code) int {
+	switch code {
+	case InvalidInput:
+		return http.StatusBadRequest
+	case NotFound:
+		return http.StatusNotFound
+	case UpstreamUnavailable, UpstreamFormatChanged:
+		return http.StatusBadGateway
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case MethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case NotConfigured:
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
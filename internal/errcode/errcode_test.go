@@ -0,0 +1,51 @@
+package errcode_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodes(t *testing.T) {
+	e := errcode.Errorf(errcode.NotFound, "no %s", "words")
+	assert.Equal(t, "no words", e.Error())
+	assert.Equal(t, errcode.NotFound, errcode.Of(errors.Wrap(e, "find")))
+	assert.Equal(t, http.StatusNotFound, errcode.HTTPStatus(errcode.Of(e)))
+
+	// the outermost code wins
+	wrapped := errcode.Wrap(e, errcode.UpstreamFormatChanged, "parse")
+	assert.Equal(t, "parse: no words", wrapped.Error())
+	assert.Equal(t, errcode.UpstreamFormatChanged, errcode.Of(wrapped))
+	assert.True(t, errors.Is(wrapped, e))
+
+	plain := fmt.Errorf("boom")
+	assert.Equal(t, errcode.Internal, errcode.Of(plain))
+	assert.Equal(t, errcode.InvalidInput, errcode.Of(errcode.WithDefault(plain, errcode.InvalidInput)))
+	assert.Equal(t, errcode.NotFound, errcode.Of(errcode.WithDefault(e, errcode.InvalidInput)))
+	assert.Equal(t, "boom", errcode.WithCode(plain, errcode.Timeout).Error())
+	assert.Nil(t, errcode.WithCode(nil, errcode.Timeout))
+
+	assert.Equal(t, errcode.Timeout, errcode.Of(errors.Wrap(context.DeadlineExceeded, "wait")))
+	assert.Equal(t, http.StatusGatewayTimeout, errcode.HTTPStatus(errcode.Timeout))
+	assert.Equal(t, http.StatusBadGateway, errcode.HTTPStatus(errcode.UpstreamUnavailable))
+	assert.Equal(t, http.StatusInternalServerError, errcode.HTTPStatus(errcode.Internal))
+	assert.Equal(t, http.StatusNotImplemented, errcode.HTTPStatus(errcode.NotConfigured))
+
+	none := errcode.NoResults("no %s found", "words")
+	assert.Equal(t, "no words found", none.Error())
+	assert.Equal(t, errcode.NotFound, errcode.Of(none))
+	assert.True(t, errcode.IsNoResults(none))
+	assert.True(t, errcode.IsNoResults(errors.Wrap(none, "find")))
+	assert.False(t, errcode.IsNoResults(errcode.WithCode(none, errcode.UpstreamUnavailable)))
+	assert.False(t, errcode.IsNoResults(e))
+	assert.False(t, errcode.IsNoResults(plain))
+
+	assert.True(t, inputerror.IsInputError(errcode.New(errcode.InvalidInput, "bad")))
+	assert.False(t, inputerror.IsInputError(e))
+}
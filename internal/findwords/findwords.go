@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/gotwarlost/crossies/internal/errcode"
 	"github.com/gotwarlost/crossies/internal/htmlplus"
 	"github.com/gotwarlost/crossies/internal/inputerror"
 	"github.com/gotwarlost/crossies/internal/synonyms"
@@ -18,37 +19,26 @@ const (
 	placeholder      = "."
 	infoPageSize     = 250
 	infoPagesPerPage = 1
+	maxSynonyms      = 5 // each synonym is looked up separately
 )
 
 var (
+	errNoWords = errcode.NoResults("no words found that match the frame")
+
 	inputRE      = regexp.MustCompile(`^[a-zA-Z.]+$`)
 	totalWordsRE = regexp.MustCompile(`There\s+are\s+(\d+)\s+`)
 	scoreRE      = regexp.MustCompile(`[(].*`)
 )
 
-func getURL(frame string, page int) (string, url.Values, error) {
-	count := len(frame)
-	specifiedCount := 0
-	word := ""
-	for i := 0; i < count; i++ {
-		ch := frame[i : i+1]
-		if ch != placeholder {
-			specifiedCount++
-			word += ch
-		} else {
-			word += "_"
-		}
-	}
-	if specifiedCount == 0 {
-		return "", nil, fmt.Errorf("inputs cannot all be dots")
-	}
+func getURL(frame string, page int) (string, url.Values) {
+	word := strings.ReplaceAll(frame, placeholder, "_")
 	return fmt.Sprintf("https://www.thewordfinder.com/wordlist/at-position-%s/", word),
 		url.Values{
 			"dir":   []string{"ascending"},
 			"field": []string{"word"},
 			"pg":    []string{fmt.Sprint(page)},
 			"size":  []string{fmt.Sprint(len(word))},
-		}, nil
+		}
 }
 
 // Query is a query to find words matching a frame.
@@ -68,6 +58,12 @@ func (q *Query) initialize() error {
 	if !inputRE.MatchString(q.Frame) {
 		return inputerror.New("inputs can only be letters or dots")
 	}
+	if strings.Trim(q.Frame, placeholder) == "" {
+		return inputerror.New("inputs cannot all be dots")
+	}
+	if len(q.Synonyms) > maxSynonyms {
+		return inputerror.New(fmt.Sprintf("at most %d synonyms may be specified", maxSynonyms))
+	}
 	return nil
 }
 
@@ -78,7 +74,7 @@ func NewQueryFromParams(values url.Values) (q Query, _ error) {
 	if pageStr != "" {
 		p, err := strconv.Atoi(pageStr)
 		if err != nil {
-			return q, errors.Wrapf(err, "page number %q", pageStr)
+			return q, inputerror.New(fmt.Sprintf("invalid page number %q", pageStr))
 		}
 		q.Page = p
 	}
@@ -116,10 +112,7 @@ func (q *Query) readPage() (*pageResult, error) {
 	if err := q.initialize(); err != nil {
 		return nil, err
 	}
-	u, query, err := getURL(q.Frame, q.Page)
-	if err != nil {
-		return nil, err
-	}
+	u, query := getURL(q.Frame, q.Page)
 
 	doc, err := htmlplus.LoadURL(u, htmlplus.LoadOptions{
 		Params: query,
@@ -130,15 +123,15 @@ func (q *Query) readPage() (*pageResult, error) {
 
 	wordCountDiv := doc.Find("div.word-criteria-heading")
 	if wordCountDiv == nil {
-		return nil, fmt.Errorf("no words found that match the frame")
+		return nil, errNoWords
 	}
 	matches := totalWordsRE.FindStringSubmatch(wordCountDiv.InnerText())
 	if matches == nil {
-		return nil, fmt.Errorf("internal error: could not find word count text")
+		return nil, errcode.New(errcode.UpstreamFormatChanged, "could not find word count text")
 	}
 	totalWords, err := strconv.Atoi(matches[1])
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %w", err)
+		return nil, errcode.Wrap(err, errcode.UpstreamFormatChanged, "word count")
 	}
 	var ret []string
 	nodes := doc.FindAll("div.word-results li.word a > span:first-child")
@@ -151,7 +144,7 @@ func (q *Query) readPage() (*pageResult, error) {
 
 	nextPage := q.Page
 	if (nextPage-1)*infoPageSize >= totalWords {
-		return nil, fmt.Errorf("read past last page")
+		return nil, errcode.Errorf(errcode.NotFound, "page %d is past the last page", q.Page)
 	}
 
 	if nextPage*infoPageSize >= totalWords {
@@ -184,6 +177,18 @@ func (q *Query) findWords() (*Result, error) {
 	return &finalResult, nil
 }
 
+// IsWord returns true if the supplied word, consisting only of letters, is a known word.
+func IsWord(word string) (bool, error) {
+	q := Query{Frame: word}
+	if _, err := q.readPage(); err != nil {
+		if errors.Is(err, errNoWords) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 type synonymsResult struct {
 	words map[string]bool
 	err   error
@@ -205,7 +210,8 @@ func (q *Query) findSynonyms(ch chan<- synonymsResult) {
 		for _, s := range m {
 			matches[s.Synonym] = true
 		}
-		if err != nil {
+		// a word without synonyms just has no matches
+		if err != nil && errcode.Of(err) != errcode.NotFound {
 			finalErr = err
 		}
 	}
@@ -215,7 +221,11 @@ func (q *Query) findSynonyms(ch chan<- synonymsResult) {
 			defer wg.Done()
 			sq := synonyms.Query{Word: word}
 			res, err := sq.Run()
-			setMatches(res.Entries, err)
+			if err != nil {
+				setMatches(nil, err)
+				return
+			}
+			setMatches(res.Entries, nil)
 		}(s)
 	}
 	wg.Wait()
@@ -225,7 +235,22 @@ func (q *Query) findSynonyms(ch chan<- synonymsResult) {
 	}
 }
 
+// matchSynonyms sets the words of the result that are also synonyms.
+func (q *Query) matchSynonyms(result *Result, syns map[string]bool) {
+	if len(q.Synonyms) == 0 {
+		return
+	}
+	for _, word := range result.Words {
+		if syns[word] {
+			result.SynonymMatches = append(result.SynonymMatches, word)
+		}
+	}
+}
+
 func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
 	ch := make(chan synonymsResult, 1)
 	q.findSynonyms(ch)
 
@@ -241,12 +266,52 @@ func (q *Query) Run() (*Result, error) {
 	}
 
 	result.Query = q
-	if len(q.Synonyms) > 0 {
-		for _, word := range result.Words {
-			if synRes.words[word] {
-				result.SynonymMatches = append(result.SynonymMatches, word)
-			}
+	q.matchSynonyms(result, synRes.words)
+	return result, nil
+}
+
+// Progress is how far a streamed query has got.
+type Progress struct {
+	PagesFetched int `json:"pagesFetched"` // pages read so far
+	TotalPages   int `json:"totalPages"`   // pages that will be read in all
+	TotalWords   int `json:"totalWords"`   // total words matching frame
+}
+
+// Stream reads pages of words from the page of the query onwards, calling fn with the result of each page as soon
+// as it is read. At most maxPages pages are read, or all of them if maxPages is not positive. Streaming stops at
+// the first error, including one returned by fn.
+func (q *Query) Stream(maxPages int, fn func(*Result, Progress) error) error {
+	if err := q.initialize(); err != nil {
+		return err
+	}
+	ch := make(chan synonymsResult, 1)
+	q.findSynonyms(ch)
+	synRes := <-ch
+	if synRes.err != nil {
+		return synRes.err
+	}
+
+	pq := *q
+	var p Progress
+	for {
+		page, err := pq.readPage()
+		if err != nil {
+			return err
+		}
+		p.PagesFetched++
+		p.TotalWords = page.totalWords
+		p.TotalPages = (page.totalWords+infoPageSize-1)/infoPageSize - q.Page + 1
+		if maxPages > 0 && p.TotalPages > maxPages {
+			p.TotalPages = maxPages
+		}
+		result := &Result{Words: page.words, NextPage: page.nextPage, TotalWords: page.totalWords}
+		q.matchSynonyms(result, synRes.words)
+		if err := fn(result, p); err != nil {
+			return err
 		}
+		if page.nextPage == 0 || p.PagesFetched >= p.TotalPages {
+			return nil
+		}
+		pq.Page = page.nextPage
 	}
-	return result, nil
 }
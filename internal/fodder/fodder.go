@@ -0,0 +1,199 @@
+package fodder
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/anagrams"
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/indicators"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+)
+
+const (
+	maxSolve       = 10 // maximum number of candidates for which anagrams are found
+	indicatorScore = 10
+	edgeScore      = 1
+)
+
+// Query is a query to find anagram fodder in the text of a clue.
+type Query struct {
+	Clue        string `json:"clue"`                  // clue text, optionally ending with an enumeration like (9)
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the answer when not part of the clue
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	Solve       bool   `json:"solve,omitempty"`       // whether to find anagrams for the best candidates
+	words       []*indicators.TaggedWord
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	text, e, ok := clue.SplitEnumeration(q.Clue)
+	enum := q.Enumeration
+	if enum == "" && ok {
+		enum = e.String()
+	}
+	q.words = indicators.Tag(text)
+	if len(q.words) == 0 {
+		return inputerror.New("empty clue not allowed")
+	}
+	var err error
+	q.pattern, err = clue.NewPattern(enum, q.Frame)
+	if err != nil {
+		return err
+	}
+	if q.pattern.Length() == 0 {
+		return inputerror.New("no enumeration or frame specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Clue = values.Get("clue")
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	q.Solve = values.Get("solve") == "true"
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Candidate is a run of clue words whose letters can be rearranged into the answer.
+type Candidate struct {
+	Fodder    string   `json:"fodder"`              // clue words making up the fodder
+	Start     int      `json:"start"`               // index of the first fodder word in the clue
+	End       int      `json:"end"`                 // index one past the last fodder word
+	Indicator string   `json:"indicator,omitempty"` // anagram indicator next to the fodder, if any
+	Score     int      `json:"score"`               // higher scores are more likely to be the real fodder
+	Anagrams  []string `json:"anagrams,omitempty"`  // anagrams of the fodder that fit the answer, when solved
+}
+
+// Result is the result of a query
+type Result struct {
+	Query      *Query       `json:"query,omitempty"`
+	Candidates []*Candidate `json:"candidates"`
+}
+
+// containsLetters returns true if the letters in want are all present in have, counting repeats.
+func containsLetters(have, want string) bool {
+	var counts [26]int
+	for i := 0; i < len(have); i++ {
+		counts[have[i]-'a']++
+	}
+	for i := 0; i < len(want); i++ {
+		counts[want[i]-'a']--
+		if counts[want[i]-'a'] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *Query) indicatorAt(index int) string {
+	if index < 0 || index >= len(q.words) {
+		return ""
+	}
+	w := q.words[index]
+	if !w.Has(indicators.Anagram) {
+		return ""
+	}
+	return w.Text
+}
+
+func (q *Query) fodderText(start, end int) string {
+	var parts []string
+	for _, w := range q.words[start:end] {
+		parts = append(parts, w.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (q *Query) candidates() []*Candidate {
+	var ret []*Candidate
+	n := len(q.words)
+	for start := 0; start < n; start++ {
+		letters := ""
+		for end := start + 1; end <= n; end++ {
+			letters += q.words[end-1].Letters
+			if len(letters) > q.pattern.Length() {
+				break
+			}
+			if len(letters) != q.pattern.Length() || !containsLetters(letters, q.pattern.Known()) {
+				continue
+			}
+			c := &Candidate{
+				Fodder: q.fodderText(start, end),
+				Start:  start,
+				End:    end,
+			}
+			c.Indicator = q.indicatorAt(start - 1)
+			if c.Indicator == "" {
+				c.Indicator = q.indicatorAt(end)
+			}
+			if c.Indicator != "" {
+				c.Score += indicatorScore
+			}
+			if start > 0 || end < n {
+				c.Score += edgeScore
+			}
+			ret = append(ret, c)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Score > ret[j].Score
+	})
+	return ret
+}
+
+func (q *Query) solve(c *Candidate) error {
+	res, err := anagrams.Solve(anagrams.Query{Phrase: strings.Join(strings.Fields(c.Fodder), "")})
+	if err != nil {
+		if code := errcode.Of(err); code == errcode.InvalidInput || code == errcode.NotFound {
+			return nil
+		}
+		return err
+	}
+	for _, p := range res.Phrases {
+		if q.pattern.Match(p) {
+			c.Anagrams = append(c.Anagrams, strings.ToLower(p))
+		}
+	}
+	return nil
+}
+
+// Run finds candidate anagram fodder in the clue, ranked by the presence of an adjacent anagram indicator.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	cands := q.candidates()
+	if len(cands) == 0 {
+		return nil, errcode.NoResults("no runs of clue words have %d letters", q.pattern.Length())
+	}
+	if q.Solve {
+		toSolve := cands
+		if len(toSolve) > maxSolve {
+			toSolve = toSolve[:maxSolve]
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, len(toSolve))
+		for i, c := range toSolve {
+			wg.Add(1)
+			go func(i int, c *Candidate) {
+				defer wg.Done()
+				errs[i] = q.solve(c)
+			}(i, c)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Result{Query: q, Candidates: cands}, nil
+}
@@ -0,0 +1,66 @@
+package fodder_test
+
+import (
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/fodder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   fodder.Query
+		fodders []string
+		first   string
+		ind     string
+	}{
+		{
+			name:    "indicator before fodder",
+			query:   fodder.Query{Clue: "Broken bone found at sea (4)"},
+			fodders: []string{"bone"},
+			first:   "bone",
+			ind:     "Broken",
+		},
+		{
+			name:    "frame letters must be present",
+			query:   fodder.Query{Clue: "Found at sea, mixed (5)", Frame: "s...."},
+			fodders: []string{"at sea"},
+			first:   "at sea",
+			ind:     "mixed",
+		},
+		{
+			name:    "enumeration outside the clue",
+			query:   fodder.Query{Clue: "Mad dear", Enumeration: "4"},
+			fodders: []string{"dear"},
+			first:   "dear",
+			ind:     "Mad",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := test.query.Run()
+			require.NoError(t, err)
+			var fodders []string
+			for _, c := range res.Candidates {
+				fodders = append(fodders, c.Fodder)
+			}
+			assert.ElementsMatch(t, test.fodders, fodders)
+			assert.Equal(t, test.first, res.Candidates[0].Fodder)
+			assert.Equal(t, test.ind, res.Candidates[0].Indicator)
+		})
+	}
+}
+
+func TestCandidateErrors(t *testing.T) {
+	q := fodder.Query{Clue: "Broken bone (9)"}
+	_, err := q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	for _, q := range []fodder.Query{{Clue: "Broken bone"}, {Clue: "", Enumeration: "4"}} {
+		_, err = q.Run()
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err))
+	}
+}
@@ -0,0 +1,273 @@
+package gridcheck
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+)
+
+const defaultMinLength = 3
+
+// Symmetry describes the symmetries of the block pattern of a grid.
+type Symmetry struct {
+	Rotational   bool `json:"rotational"`   // unchanged by a half turn
+	QuarterTurn  bool `json:"quarterTurn"`  // unchanged by a quarter turn, square grids only
+	LeftRight    bool `json:"leftRight"`    // mirrored about the vertical axis
+	TopBottom    bool `json:"topBottom"`    // mirrored about the horizontal axis
+	Diagonal     bool `json:"diagonal"`     // mirrored about the leading diagonal, square grids only
+	AntiDiagonal bool `json:"antiDiagonal"` // mirrored about the other diagonal, square grids only
+}
+
+// Any returns true if the grid has any symmetry.
+func (s Symmetry) Any() bool {
+	return s.Rotational || s.QuarterTurn || s.LeftRight || s.TopBottom || s.Diagonal || s.AntiDiagonal
+}
+
+// Square is the position of a square in the grid.
+type Square struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// Duplicate is a word that appears in more than one slot.
+type Duplicate struct {
+	Word   string   `json:"word"`
+	Labels []string `json:"labels"`
+}
+
+// Letters is the distribution of letters in the filled squares.
+type Letters struct {
+	Filled  int            `json:"filled"`            // number of squares with a letter
+	Counts  map[string]int `json:"counts,omitempty"`  // occurrences of each letter
+	Missing []string       `json:"missing,omitempty"` // letters of the alphabet that do not appear
+	Pangram bool           `json:"pangram"`
+}
+
+// Report is the result of checking a grid.
+type Report struct {
+	Width         int         `json:"width"`
+	Height        int         `json:"height"`
+	Symmetry      Symmetry    `json:"symmetry"`
+	Connected     bool        `json:"connected"` // every white square can be reached from every other
+	Regions       int         `json:"regions"`   // number of separate areas of white squares
+	Words         int         `json:"words"`
+	Blocks        int         `json:"blocks"`
+	WhiteSquares  int         `json:"whiteSquares"`
+	AverageLength float64     `json:"averageLength"`
+	Lengths       map[int]int `json:"lengths"`              // number of words of each length
+	Unchecked     []Square    `json:"unchecked,omitempty"`  // white squares in only one word
+	Unused        []Square    `json:"unused,omitempty"`     // white squares in no word at all
+	ShortWords    []string    `json:"shortWords,omitempty"` // labels of words below the minimum length
+	Duplicates    []Duplicate `json:"duplicates,omitempty"` // complete words used more than once
+	Letters       Letters     `json:"letters"`
+	Problems      []string    `json:"problems,omitempty"` // summary of everything above that needs attention
+}
+
+// Check checks the grid, treating words shorter than minLength as problems.
+func Check(p *puzzle.Puzzle, minLength int) *Report {
+	if minLength <= 0 {
+		minLength = defaultMinLength
+	}
+	r := &Report{Width: p.Width, Height: p.Height, Lengths: map[int]int{}}
+	r.Symmetry = symmetry(p)
+	r.Regions = regions(p)
+	r.Connected = r.Regions <= 1
+
+	inWords := make([]int, len(p.Cells))
+	words := map[string][]string{}
+	total := 0
+	for _, s := range p.Slots() {
+		r.Words++
+		r.Lengths[s.Length]++
+		total += s.Length
+		for _, pos := range s.Positions() {
+			inWords[pos[0]*p.Width+pos[1]]++
+		}
+		if s.Length < minLength {
+			r.ShortWords = append(r.ShortWords, s.Label())
+		}
+		if answer := p.Answer(s); !strings.Contains(answer, ".") {
+			words[answer] = append(words[answer], s.Label())
+		}
+	}
+	if r.Words > 0 {
+		r.AverageLength = float64(total) / float64(r.Words)
+	}
+	for i, c := range p.Cells {
+		if c.Block {
+			r.Blocks++
+			continue
+		}
+		r.WhiteSquares++
+		sq := Square{Row: i / p.Width, Col: i % p.Width}
+		switch inWords[i] {
+		case 0:
+			r.Unused = append(r.Unused, sq)
+		case 1:
+			r.Unchecked = append(r.Unchecked, sq)
+		}
+	}
+	for w, labels := range words {
+		if len(labels) > 1 {
+			r.Duplicates = append(r.Duplicates, Duplicate{Word: w, Labels: labels})
+		}
+	}
+	sort.Slice(r.Duplicates, func(i, j int) bool { return r.Duplicates[i].Word < r.Duplicates[j].Word })
+	r.Letters = letters(p)
+	r.Problems = problems(r, minLength)
+	return r
+}
+
+func problems(r *Report, minLength int) []string {
+	var ret []string
+	if !r.Symmetry.Any() {
+		ret = append(ret, "grid has no symmetry")
+	}
+	if !r.Connected {
+		ret = append(ret, fmt.Sprintf("white squares form %d separate areas", r.Regions))
+	}
+	if n := len(r.Unchecked); n > 0 {
+		ret = append(ret, fmt.Sprintf("%d unchecked squares", n))
+	}
+	if n := len(r.Unused); n > 0 {
+		ret = append(ret, fmt.Sprintf("%d squares are not in any word", n))
+	}
+	if len(r.ShortWords) > 0 {
+		ret = append(ret, fmt.Sprintf("words shorter than %d letters: %s", minLength, strings.Join(r.ShortWords, ", ")))
+	}
+	for _, d := range r.Duplicates {
+		ret = append(ret, fmt.Sprintf("%s is used more than once: %s", strings.ToUpper(d.Word), strings.Join(d.Labels, ", ")))
+	}
+	return ret
+}
+
+func symmetry(p *puzzle.Puzzle) Symmetry {
+	w, h := p.Width, p.Height
+	check := func(square bool, mapping func(r, c int) (int, int)) bool {
+		if square && w != h {
+			return false
+		}
+		for r := 0; r < h; r++ {
+			for c := 0; c < w; c++ {
+				r2, c2 := mapping(r, c)
+				if p.Cell(r, c).Block != p.Cell(r2, c2).Block {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return Symmetry{
+		Rotational:   check(false, func(r, c int) (int, int) { return h - 1 - r, w - 1 - c }),
+		QuarterTurn:  check(true, func(r, c int) (int, int) { return c, w - 1 - r }),
+		LeftRight:    check(false, func(r, c int) (int, int) { return r, w - 1 - c }),
+		TopBottom:    check(false, func(r, c int) (int, int) { return h - 1 - r, c }),
+		Diagonal:     check(true, func(r, c int) (int, int) { return c, r }),
+		AntiDiagonal: check(true, func(r, c int) (int, int) { return w - 1 - c, h - 1 - r }),
+	}
+}
+
+// regions returns the number of connected areas of white squares.
+func regions(p *puzzle.Puzzle) int {
+	seen := make([]bool, len(p.Cells))
+	count := 0
+	for start, c := range p.Cells {
+		if c.Block || seen[start] {
+			continue
+		}
+		count++
+		stack := []int{start}
+		seen[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			r, c := i/p.Width, i%p.Width
+			for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				cell := p.Cell(r+d[0], c+d[1])
+				j := (r+d[0])*p.Width + c + d[1]
+				if cell != nil && !cell.Block && !seen[j] {
+					seen[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+	}
+	return count
+}
+
+func letters(p *puzzle.Puzzle) Letters {
+	l := Letters{Counts: map[string]int{}}
+	for _, c := range p.Cells {
+		if c.Block || c.Solution == "" {
+			continue
+		}
+		l.Filled++
+		for _, ch := range strings.ToUpper(c.Solution) {
+			if ch >= 'A' && ch <= 'Z' {
+				l.Counts[string(ch)]++
+			}
+		}
+	}
+	for ch := 'A'; ch <= 'Z'; ch++ {
+		if l.Counts[string(ch)] == 0 {
+			l.Missing = append(l.Missing, string(ch))
+		}
+	}
+	l.Pangram = len(l.Missing) == 0
+	return l
+}
+
+// Query is a query to check a grid in the text format.
+type Query struct {
+	Grid      string `json:"grid"`                // rows separated by new lines or slashes
+	MinLength int    `json:"minLength,omitempty"` // minimum word length, 3 if not specified
+	puzzle    *puzzle.Puzzle
+}
+
+func (q *Query) initialize() error {
+	rows := strings.ReplaceAll(q.Grid, "/", "\n")
+	p, err := puzzle.ParseGrid(strings.NewReader(rows))
+	if err != nil {
+		return err
+	}
+	q.puzzle = p
+	if q.MinLength < 0 {
+		return inputerror.New(fmt.Sprintf("invalid minimum length %d", q.MinLength))
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Grid = values.Get("grid")
+	if str := values.Get("minLength"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return q, inputerror.New(fmt.Sprintf("invalid minimum length %q", str))
+		}
+		q.MinLength = n
+	}
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Result is the result of a grid check query.
+type Result struct {
+	Query *Query `json:"query,omitempty"`
+	*Report
+}
+
+// Run checks the grid in the query.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	return &Result{Query: q, Report: Check(q.puzzle, q.MinLength)}, nil
+}
@@ -0,0 +1,44 @@
+package gridcheck_test
+
+import (
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/gridcheck"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	q := gridcheck.Query{Grid: "#CAT#/HORSE/OR#IT/TESTS/#CAT#"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.True(t, res.Symmetry.Rotational)
+	assert.True(t, res.Symmetry.LeftRight)
+	assert.True(t, res.Symmetry.Diagonal)
+	assert.True(t, res.Connected)
+	assert.Equal(t, 5, res.Blocks)
+	assert.Equal(t, 12, res.Words)
+	assert.Equal(t, map[int]int{2: 4, 3: 4, 5: 4}, res.Lengths)
+	assert.Equal(t, []string{"2D", "6A", "7A", "9D"}, res.ShortWords)
+	require.Len(t, res.Duplicates, 1)
+	assert.Equal(t, gridcheck.Duplicate{Word: "cat", Labels: []string{"1A", "10A"}}, res.Duplicates[0])
+	assert.Equal(t, 5, res.Letters.Counts["T"])
+	assert.False(t, res.Letters.Pangram)
+	assert.Empty(t, res.Unchecked)
+}
+
+func TestCheckProblems(t *testing.T) {
+	q := gridcheck.Query{Grid: "...#\n...#\n####\n#..."}
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.False(t, res.Connected)
+	assert.Equal(t, 2, res.Regions)
+	assert.False(t, res.Symmetry.Any())
+	assert.Equal(t, []gridcheck.Square{{Row: 3, Col: 1}, {Row: 3, Col: 2}, {Row: 3, Col: 3}}, res.Unchecked)
+	assert.Len(t, res.Problems, 4)
+
+	q = gridcheck.Query{Grid: "..?/..."}
+	_, err = q.Run()
+	assert.True(t, inputerror.IsInputError(err))
+}
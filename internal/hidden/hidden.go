@@ -0,0 +1,156 @@
+package hidden
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+)
+
+// Query is a query to find words hidden in clue text.
+type Query struct {
+	Clue        string `json:"clue"`                  // clue text, optionally ending with an enumeration like (5)
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the answer when not part of the clue
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	text        string // clue text without the enumeration
+	offset      int    // byte offset of the text in the clue
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	text, e, ok := clue.SplitEnumeration(q.Clue)
+	enum := q.Enumeration
+	if enum == "" && ok {
+		enum = e.String()
+	}
+	if clue.Letters(text) == "" {
+		return inputerror.New("empty clue not allowed")
+	}
+	q.text = text
+	q.offset = len(q.Clue) - len(strings.TrimLeftFunc(q.Clue, unicode.IsSpace))
+	var err error
+	q.pattern, err = clue.NewPattern(enum, q.Frame)
+	if err != nil {
+		return err
+	}
+	if q.pattern.Length() == 0 {
+		return inputerror.New("no enumeration or frame specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Clue = values.Get("clue")
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Entry is a word hidden in the clue.
+type Entry struct {
+	Word     string `json:"word"`               // the hidden word
+	Span     string `json:"span"`               // the part of the clue that contains the word
+	Start    int    `json:"start"`              // byte offset of the span in the clue
+	End      int    `json:"end"`                // byte offset one past the end of the span
+	Reversed bool   `json:"reversed,omitempty"` // true if the word is hidden backwards
+	Spanning bool   `json:"spanning,omitempty"` // true if the word spans more than one clue word
+}
+
+// Result is the result of a query
+type Result struct {
+	Query   *Query   `json:"query,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// candidates returns every substring of the clue letters of the required length, forwards and backwards.
+func (q *Query) candidates() []*Entry {
+	var letters []byte
+	var offsets []int
+	// bytes of the text itself are folded so that the offsets index it even when lowercasing other runes
+	// would change their length
+	for i := 0; i < len(q.text); i++ {
+		if ch := byte(unicode.ToLower(rune(q.text[i]))); ch >= 'a' && ch <= 'z' {
+			letters = append(letters, ch)
+			offsets = append(offsets, i)
+		}
+	}
+	n := q.pattern.Length()
+	var ret []*Entry
+	for i := 0; i+n <= len(letters); i++ {
+		start, end := offsets[i], offsets[i+n-1]+1
+		span := q.text[start:end]
+		spanning := strings.IndexFunc(span, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '\'')
+		}) >= 0
+		word := string(letters[i : i+n])
+		for _, rev := range []bool{false, true} {
+			w := word
+			if rev {
+				w = reverse(word)
+			}
+			if !q.pattern.Match(w) {
+				continue
+			}
+			ret = append(ret, &Entry{
+				Word:     w,
+				Span:     span,
+				Start:    q.offset + start,
+				End:      q.offset + end,
+				Reversed: rev,
+				Spanning: spanning,
+			})
+		}
+	}
+	return ret
+}
+
+// Run finds real words of the required length hidden in the clue, forwards or reversed, checking
+// candidates against the default word source.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	cands := q.candidates()
+	var words []string
+	for _, c := range cands {
+		words = append(words, c.Word)
+	}
+	found, err := wordlist.Default().Words(words)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, c := range cands {
+		if found[c.Word] {
+			entries = append(entries, c)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errcode.NoResults("no hidden words of length %d found", q.pattern.Length())
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		left, right := entries[i], entries[j]
+		if left.Spanning != right.Spanning {
+			return left.Spanning
+		}
+		return !left.Reversed && right.Reversed
+	})
+	return &Result{Query: q, Entries: entries}, nil
+}
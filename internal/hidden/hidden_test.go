@@ -0,0 +1,69 @@
+package hidden_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/hidden"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	wordlist.SetDefault(wordlist.New([]string{"pock", "tina", "anit", "cart", "ab"}))
+	os.Exit(m.Run())
+}
+
+func TestHidden(t *testing.T) {
+	q := hidden.Query{Clue: "  Tablet in a pocket (4)"}
+	res, err := q.Run()
+	require.NoError(t, err)
+
+	type entry struct {
+		word, span         string
+		reversed, spanning bool
+	}
+	var got []entry
+	for _, e := range res.Entries {
+		got = append(got, entry{e.Word, e.Span, e.Reversed, e.Spanning})
+		// offsets are in the clue as supplied
+		assert.Equal(t, e.Span, q.Clue[e.Start:e.End])
+	}
+	assert.Equal(t, []entry{
+		{"tina", "t in a", false, true},
+		{"anit", "t in a", true, true},
+		{"pock", "pock", false, false},
+	}, got)
+	assert.Equal(t, 7, res.Entries[0].Start)
+}
+
+func TestHiddenNonASCII(t *testing.T) {
+	// lowercasing these runes changes their length in bytes
+	for _, clue := range []string{"ȺȺȺȺ tin apple (4)", "İİ tin apple (4)", "ȺȺȺȺ ab (2)"} {
+		q := hidden.Query{Clue: clue}
+		res, err := q.Run()
+		require.NoError(t, err, clue)
+		for _, e := range res.Entries {
+			assert.Equal(t, e.Span, q.Clue[e.Start:e.End], clue)
+		}
+	}
+}
+
+func TestHiddenFrame(t *testing.T) {
+	q := hidden.Query{Clue: "Tablet in a pocket", Enumeration: "4", Frame: "...a"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+	assert.Equal(t, "tina", res.Entries[0].Word)
+
+	q = hidden.Query{Clue: "Tablet in a pocket (6)"}
+	_, err = q.Run()
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+
+	for _, q := range []hidden.Query{{Clue: "Tablet in a pocket"}, {Clue: "(4)"}} {
+		_, err = q.Run()
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err))
+	}
+}
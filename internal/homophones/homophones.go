@@ -0,0 +1,341 @@
+package homophones
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxTolerance      = 3
+	maxPronunciations = 16 // limit on pronunciations of a phrase, which grow with every word
+)
+
+// Dictionary is a pronouncing dictionary indexed by phoneme sequence.
+type Dictionary struct {
+	prons    map[string][][]string // word to its pronunciations
+	byKey    map[string][]string   // phoneme sequence to words
+	keys     map[int][]string      // phoneme sequences by number of phonemes
+	spelling map[string]string     // word to its spelling in the dictionary
+}
+
+// Load loads a dictionary in the CMU Pronouncing Dictionary format, where each line has a word followed
+// by its phonemes, alternate pronunciations are marked as WORD(1), and comments start with ;;;.
+// Stress markers on vowels are ignored.
+func Load(r io.Reader) (*Dictionary, error) {
+	d := &Dictionary{
+		prons:    map[string][][]string{},
+		byKey:    map[string][]string{},
+		keys:     map[int][]string{},
+		spelling: map[string]string{},
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";;;") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		word := fields[0]
+		if pos := strings.Index(word, "("); pos > 0 {
+			word = word[:pos]
+		}
+		// the newer cmudict format allows a trailing comment after a hash
+		var phones []string
+		for _, p := range fields[1:] {
+			if strings.HasPrefix(p, "#") {
+				break
+			}
+			phones = append(phones, strings.TrimRight(strings.ToUpper(p), "012"))
+		}
+		letters := clue.Letters(word)
+		if letters == "" || len(phones) == 0 {
+			continue
+		}
+		d.add(letters, strings.ToLower(word), phones)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read pronouncing dictionary")
+	}
+	return d, nil
+}
+
+func (d *Dictionary) add(letters, spelling string, phones []string) {
+	key := strings.Join(phones, " ")
+	for _, p := range d.prons[letters] {
+		if strings.Join(p, " ") == key {
+			return
+		}
+	}
+	if _, ok := d.spelling[letters]; !ok {
+		d.spelling[letters] = spelling
+	}
+	d.prons[letters] = append(d.prons[letters], phones)
+	if _, ok := d.byKey[key]; !ok {
+		d.keys[len(phones)] = append(d.keys[len(phones)], key)
+	}
+	d.byKey[key] = append(d.byKey[key], letters)
+}
+
+// LoadFile loads a pronouncing dictionary from the supplied file.
+func LoadFile(file string) (*Dictionary, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	d, err := Load(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "load %s", file)
+	}
+	return d, nil
+}
+
+// Pronunciations returns the pronunciations of the supplied word or phrase, each as a sequence of phonemes.
+// Phrase pronunciations are formed from the pronunciations of each word.
+func (d *Dictionary) Pronunciations(phrase string) [][]string {
+	ret := [][]string{nil}
+	for _, w := range clue.Split(phrase) {
+		prons := d.prons[w.Letters]
+		if len(prons) == 0 {
+			return nil
+		}
+		var next [][]string
+		for _, prefix := range ret {
+			for _, p := range prons {
+				if len(next) == maxPronunciations {
+					break
+				}
+				seq := append(append([]string{}, prefix...), p...)
+				next = append(next, seq)
+			}
+		}
+		ret = next
+	}
+	if len(ret) == 1 && ret[0] == nil {
+		return nil
+	}
+	return ret
+}
+
+// distance returns the edit distance between two phoneme sequences, or max+1 if it exceeds max.
+func distance(a, b []string, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// similar returns the phoneme sequences within the supplied edit distance of a pronunciation,
+// mapped to their distance.
+func (d *Dictionary) similar(pron []string, tolerance int) map[string]int {
+	key := strings.Join(pron, " ")
+	if tolerance == 0 {
+		if _, ok := d.byKey[key]; ok {
+			return map[string]int{key: 0}
+		}
+		return nil
+	}
+	ret := map[string]int{}
+	for n := len(pron) - tolerance; n <= len(pron)+tolerance; n++ {
+		for _, k := range d.keys[n] {
+			if dist := distance(pron, strings.Fields(k), tolerance); dist <= tolerance {
+				ret[k] = dist
+			}
+		}
+	}
+	return ret
+}
+
+var (
+	defaultLock sync.RWMutex
+	defaultDict *Dictionary
+)
+
+// SetDefault sets the dictionary used by queries.
+func SetDefault(d *Dictionary) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultDict = d
+}
+
+// Default returns the dictionary used by queries, nil if none has been loaded.
+func Default() *Dictionary {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultDict
+}
+
+// Query is a query for homophones.
+type Query struct {
+	Word        string `json:"word"`                  // word or phrase for which to find homophones
+	Tolerance   int    `json:"tolerance,omitempty"`   // maximum phoneme edits for near-homophones, 0 for exact ones
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the answer
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	MinLetters  int    `json:"minLetters,omitempty"`  // min letters in homophone
+	MaxLetters  int    `json:"maxLetters,omitempty"`  // max letters in homophone
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	if clue.Letters(q.Word) == "" {
+		return inputerror.New("no word specified")
+	}
+	if q.Tolerance < 0 || q.Tolerance > maxTolerance {
+		return inputerror.New(fmt.Sprintf("tolerance must be between 0 and %d", maxTolerance))
+	}
+	if q.MinLetters > 0 && q.MaxLetters > 0 && q.MinLetters > q.MaxLetters {
+		q.MinLetters, q.MaxLetters = q.MaxLetters, q.MinLetters
+	}
+	var err error
+	q.pattern, err = clue.NewPattern(q.Enumeration, q.Frame)
+	return err
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Word = values.Get("word")
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	ints := map[string]*int{
+		"tolerance":  &q.Tolerance,
+		"minLetters": &q.MinLetters,
+		"maxLetters": &q.MaxLetters,
+	}
+	for name, ptr := range ints {
+		if s := values.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return q, inputerror.New(fmt.Sprintf("invalid %s %q", name, s))
+			}
+			*ptr = n
+		}
+	}
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+func (q *Query) shouldInclude(word string) bool {
+	if q.MinLetters != 0 && len(word) < q.MinLetters {
+		return false
+	}
+	if q.MaxLetters != 0 && len(word) > q.MaxLetters {
+		return false
+	}
+	return q.pattern.Match(word)
+}
+
+// Entry is a homophone of the query word.
+type Entry struct {
+	Word          string `json:"word"`
+	Pronunciation string `json:"pronunciation"`      // phonemes of the homophone
+	Distance      int    `json:"distance,omitempty"` // phoneme edits from the query word, 0 for exact homophones
+}
+
+// Result is the result of a homophone query.
+type Result struct {
+	Query          *Query   `json:"query,omitempty"`
+	Pronunciations []string `json:"pronunciations"` // pronunciations of the query word
+	Entries        []*Entry `json:"entries"`
+}
+
+// Run finds homophones of the query word in the default dictionary.
+func (q *Query) Run() (*Result, error) {
+	d := Default()
+	if d == nil {
+		return nil, errcode.New(errcode.NotConfigured, "no pronouncing dictionary has been loaded")
+	}
+	return q.RunWithDictionary(d)
+}
+
+// RunWithDictionary finds homophones of the query word in the supplied dictionary.
+func (q *Query) RunWithDictionary(d *Dictionary) (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	prons := d.Pronunciations(q.Word)
+	if len(prons) == 0 {
+		return nil, errcode.NoResults("no pronunciation found for %q", q.Word)
+	}
+	self := clue.Letters(q.Word)
+	best := map[string]*Entry{}
+	res := &Result{Query: q}
+	for _, p := range prons {
+		res.Pronunciations = append(res.Pronunciations, strings.Join(p, " "))
+		for key, dist := range d.similar(p, q.Tolerance) {
+			for _, w := range d.byKey[key] {
+				if w == self || !q.shouldInclude(w) {
+					continue
+				}
+				if e, ok := best[w]; ok && e.Distance <= dist {
+					continue
+				}
+				best[w] = &Entry{Word: d.spelling[w], Pronunciation: key, Distance: dist}
+			}
+		}
+	}
+	if len(best) == 0 {
+		return nil, errcode.NoResults("no homophones found for %q", q.Word)
+	}
+	for _, e := range best {
+		res.Entries = append(res.Entries, e)
+	}
+	sort.Slice(res.Entries, func(i, j int) bool {
+		left, right := res.Entries[i], res.Entries[j]
+		if left.Distance != right.Distance {
+			return left.Distance < right.Distance
+		}
+		return left.Word < right.Word
+	})
+	return res, nil
+}
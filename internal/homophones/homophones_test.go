@@ -0,0 +1,79 @@
+package homophones_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/homophones"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDict = `;;; test dictionary
+KNIGHT  N AY1 T
+NIGHT  N AY1 T
+NITE  N AY1 T
+NIGHTS  N AY1 T S
+KITE  K AY1 T
+READ  R IY1 D
+READ(1)  R EH1 D
+RED  R EH1 D
+REED  R IY1 D
+WE  W IY1
+HEAR  HH IY1 R
+WEE  W IY1
+HERE  HH IY1 R
+`
+
+func load(t *testing.T) *homophones.Dictionary {
+	d, err := homophones.Load(strings.NewReader(testDict))
+	require.NoError(t, err)
+	return d
+}
+
+func words(r *homophones.Result) []string {
+	var ret []string
+	for _, e := range r.Entries {
+		ret = append(ret, e.Word)
+	}
+	return ret
+}
+
+func TestExact(t *testing.T) {
+	d := load(t)
+	q := homophones.Query{Word: "knight"}
+	res, err := q.RunWithDictionary(d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"night", "nite"}, words(res))
+
+	q = homophones.Query{Word: "read"}
+	res, err = q.RunWithDictionary(d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"red", "reed"}, words(res))
+	assert.Len(t, res.Pronunciations, 2)
+}
+
+func TestNear(t *testing.T) {
+	d := load(t)
+	q := homophones.Query{Word: "knight", Tolerance: 1}
+	res, err := q.RunWithDictionary(d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"night", "nite", "kite", "nights"}, words(res))
+	assert.Equal(t, 1, res.Entries[2].Distance)
+
+	q = homophones.Query{Word: "knight", Tolerance: 1, Frame: "n....."}
+	res, err = q.RunWithDictionary(d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nights"}, words(res))
+}
+
+func TestPhrase(t *testing.T) {
+	d := load(t)
+	assert.Equal(t, [][]string{{"W", "IY", "HH", "IY", "R"}}, d.Pronunciations("we hear"))
+
+	q := homophones.Query{Word: "xyzzy"}
+	_, err := q.RunWithDictionary(d)
+	require.Error(t, err)
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/andybalholm/cascadia"
+	"github.com/gotwarlost/crossies/internal/errcode"
 	"github.com/pkg/errors"
 	"golang.org/x/net/html"
 )
@@ -212,19 +213,22 @@ func LoadURL(u string, opts LoadOptions) (*Document, error) {
 	}
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, errcode.WithDefault(err, errcode.UpstreamUnavailable)
 	}
 	defer func() { _ = res.Body.Close() }()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s %s return status %d", opts.Method, u, res.StatusCode)
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, errcode.Errorf(errcode.NotFound, "%s %s return status %d", opts.Method, u, res.StatusCode)
+	case res.StatusCode != http.StatusOK:
+		return nil, errcode.Errorf(errcode.UpstreamUnavailable, "%s %s return status %d", opts.Method, u, res.StatusCode)
 	}
 	b, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, errors.Wrap(err, "read response body")
+		return nil, errcode.WithDefault(errors.Wrap(err, "read response body"), errcode.UpstreamUnavailable)
 	}
 	doc, err := Load(bytes.NewReader(b))
 	if err != nil {
-		return nil, errors.Wrap(err, "read and parse HTML")
+		return nil, errcode.Wrap(err, errcode.UpstreamFormatChanged, "read and parse HTML")
 	}
 	return doc, nil
 }
@@ -0,0 +1,262 @@
+package indicators
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+// Type is the kind of wordplay that an indicator signals.
+type Type string
+
+// available indicator types
+const (
+	Anagram   Type = "anagram"
+	Reversal  Type = "reversal"
+	Hidden    Type = "hidden"
+	Container Type = "container"
+	Deletion  Type = "deletion"
+	Homophone Type = "homophone"
+	Initial   Type = "initial"
+)
+
+var allTypes = map[Type]bool{
+	Anagram:   true,
+	Reversal:  true,
+	Hidden:    true,
+	Container: true,
+	Deletion:  true,
+	Homophone: true,
+	Initial:   true,
+}
+
+// Lexicon is a set of indicator words and phrases along with the types they signal.
+type Lexicon struct {
+	l        sync.RWMutex
+	entries  map[string]map[Type]bool
+	maxWords int
+}
+
+// New returns an empty lexicon.
+func New() *Lexicon {
+	return &Lexicon{entries: map[string]map[Type]bool{}}
+}
+
+var defaultLexicon = func() *Lexicon {
+	l := New()
+	if err := l.Load(strings.NewReader(builtin)); err != nil {
+		panic(errors.Wrap(err, "load builtin indicators"))
+	}
+	return l
+}()
+
+// Default returns the lexicon containing the built-in indicators and any user additions.
+func Default() *Lexicon {
+	return defaultLexicon
+}
+
+// LoadFile adds the indicators in the supplied file to the default lexicon.
+func LoadFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return errors.Wrapf(defaultLexicon.Load(f), "load %s", file)
+}
+
+func normalize(phrase string) string {
+	var parts []string
+	for _, w := range clue.Split(phrase) {
+		parts = append(parts, w.Letters)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Add adds the supplied words or phrases as indicators of the supplied type.
+func (l *Lexicon) Add(t Type, phrases ...string) error {
+	if !allTypes[t] {
+		return inputerror.New(fmt.Sprintf("invalid indicator type %q", t))
+	}
+	l.l.Lock()
+	defer l.l.Unlock()
+	for _, p := range phrases {
+		key := normalize(p)
+		if key == "" {
+			continue
+		}
+		if l.entries[key] == nil {
+			l.entries[key] = map[Type]bool{}
+		}
+		l.entries[key][t] = true
+		if n := strings.Count(key, " ") + 1; n > l.maxWords {
+			l.maxWords = n
+		}
+	}
+	return nil
+}
+
+// Load adds indicators from the supplied reader. The input consists of sections that start with an
+// indicator type in square brackets, for example [anagram], followed by one word or phrase per line.
+// Blank lines and lines starting with # are ignored.
+func (l *Lexicon) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	var current Type
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = Type(strings.ToLower(strings.TrimSpace(line[1 : len(line)-1])))
+			if !allTypes[current] {
+				return inputerror.New(fmt.Sprintf("line %d: invalid indicator type %q", lineNo, current))
+			}
+			continue
+		}
+		if current == "" {
+			return inputerror.New(fmt.Sprintf("line %d: indicator %q found before any type", lineNo, line))
+		}
+		if err := l.Add(current, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Lookup returns the indicator types that the supplied word or phrase could signal.
+func (l *Lexicon) Lookup(phrase string) []Type {
+	l.l.RLock()
+	defer l.l.RUnlock()
+	return sortedTypes(l.entries[normalize(phrase)])
+}
+
+func sortedTypes(m map[Type]bool) []Type {
+	var ret []Type
+	for t := range m {
+		ret = append(ret, t)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// TaggedWord is a clue word along with the indicator types it could be part of.
+type TaggedWord struct {
+	clue.Word
+	Types   []Type   `json:"types,omitempty"`   // indicator types that the word could signal
+	Phrases []string `json:"phrases,omitempty"` // indicator phrases that include the word
+}
+
+// Has returns true if the word could be part of an indicator of the supplied type.
+func (t *TaggedWord) Has(typ Type) bool {
+	for _, x := range t.Types {
+		if x == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// TagWords returns the supplied words tagged with the indicator types they could signal, taking
+// multi-word indicator phrases into account. The returned slice has one element per input word.
+func (l *Lexicon) TagWords(words []clue.Word) []*TaggedWord {
+	l.l.RLock()
+	defer l.l.RUnlock()
+	types := make([]map[Type]bool, len(words))
+	ret := make([]*TaggedWord, len(words))
+	for i, w := range words {
+		ret[i] = &TaggedWord{Word: w}
+		types[i] = map[Type]bool{}
+	}
+	for start := range words {
+		key := ""
+		for end := start + 1; end <= len(words) && end-start <= l.maxWords; end++ {
+			if key != "" {
+				key += " "
+			}
+			key += words[end-1].Letters
+			entry := l.entries[key]
+			if entry == nil {
+				continue
+			}
+			for i := start; i < end; i++ {
+				for t := range entry {
+					types[i][t] = true
+				}
+				ret[i].Phrases = append(ret[i].Phrases, key)
+			}
+		}
+	}
+	for i := range ret {
+		ret[i].Types = sortedTypes(types[i])
+	}
+	return ret
+}
+
+// Tag tags every word in the supplied clue text with the indicator types it could signal using
+// the default lexicon.
+func Tag(text string) []*TaggedWord {
+	return defaultLexicon.TagWords(clue.Split(text))
+}
+
+// TagWords tags the supplied words using the default lexicon.
+func TagWords(words []clue.Word) []*TaggedWord {
+	return defaultLexicon.TagWords(words)
+}
+
+// Query is a query to look up indicators.
+type Query struct {
+	Word string `json:"word,omitempty"` // word or phrase to look up
+	Clue string `json:"clue,omitempty"` // clue text whose words should be tagged
+}
+
+func (q *Query) initialize() error {
+	if q.Word == "" && q.Clue == "" {
+		return inputerror.New("no word or clue specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Word = values.Get("word")
+	q.Clue = values.Get("clue")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Result is the result of an indicator query.
+type Result struct {
+	Query *Query        `json:"query,omitempty"`
+	Types []Type        `json:"types,omitempty"` // indicator types for the word
+	Words []*TaggedWord `json:"words,omitempty"` // tagged clue words
+}
+
+// Run looks up the query word and tags the query clue using the default lexicon.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	ret := &Result{Query: q}
+	if q.Word != "" {
+		ret.Types = defaultLexicon.Lookup(q.Word)
+	}
+	if q.Clue != "" {
+		text, _, _ := clue.SplitEnumeration(q.Clue)
+		ret.Words = Tag(text)
+	}
+	return ret, nil
+}
@@ -0,0 +1,98 @@
+package indicators_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/indicators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLexicon = `
+# test indicators
+[anagram]
+in a mess
+upset
+
+[reversal]
+upset
+going back
+
+[homophone]
+sounds like
+`
+
+func TestTagWords(t *testing.T) {
+	l := indicators.New()
+	require.NoError(t, l.Load(strings.NewReader(testLexicon)))
+
+	tests := []struct {
+		text    string
+		types   [][]indicators.Type
+		phrases []string // phrases of the last word
+	}{
+		{
+			text:  "Upset rat",
+			types: [][]indicators.Type{{indicators.Anagram, indicators.Reversal}, nil},
+		},
+		{
+			text:    "Tins in a mess",
+			types:   [][]indicators.Type{nil, {indicators.Anagram}, {indicators.Anagram}, {indicators.Anagram}},
+			phrases: []string{"in a mess"},
+		},
+		{
+			text:    "Sheep going back, it sounds like",
+			types:   [][]indicators.Type{nil, {indicators.Reversal}, {indicators.Reversal}, nil, {indicators.Homophone}, {indicators.Homophone}},
+			phrases: []string{"sounds like"},
+		},
+		{
+			// a phrase only counts when all of its words are present
+			text:  "Going in a",
+			types: [][]indicators.Type{nil, nil, nil},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			words := l.TagWords(clue.Split(test.text))
+			require.Len(t, words, len(test.types))
+			for i, w := range words {
+				assert.Equal(t, test.types[i], w.Types, "word %d", i)
+			}
+			assert.Equal(t, test.phrases, words[len(words)-1].Phrases)
+		})
+	}
+	assert.Equal(t, []indicators.Type{indicators.Anagram}, l.Lookup("In  a MESS"))
+	assert.Nil(t, l.Lookup("in a"))
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		input, msg string
+	}{
+		{"[jumble]\nfoo\n", `line 1: invalid indicator type "jumble"`},
+		{"# comment\nfoo\n", `line 2: indicator "foo" found before any type`},
+	}
+	for _, test := range tests {
+		err := indicators.New().Load(strings.NewReader(test.input))
+		require.Error(t, err)
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err))
+		assert.Equal(t, test.msg, err.Error())
+	}
+	assert.Error(t, indicators.New().Add("jumble", "foo"))
+}
+
+func TestDefaultLexicon(t *testing.T) {
+	q := indicators.Query{Word: "broken", Clue: "Broken bone (4)"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	assert.Contains(t, res.Types, indicators.Anagram)
+	require.Len(t, res.Words, 2)
+	assert.True(t, res.Words[0].Has(indicators.Anagram))
+	assert.False(t, res.Words[1].Has(indicators.Anagram))
+
+	_, err = (&indicators.Query{}).Run()
+	assert.Equal(t, errcode.InvalidInput, errcode.Of(err))
+}
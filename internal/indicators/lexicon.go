@@ -0,0 +1,238 @@
+package indicators
+
+// builtin is the default indicator lexicon. Each section starts with the indicator type in square
+// brackets followed by one indicator word or phrase per line.
+const builtin = `
+[anagram]
+about
+abroad
+abnormal
+adapted
+adjusted
+altered
+amended
+anew
+around
+arranged
+assorted
+astray
+awful
+awkward
+awry
+bad
+badly
+bizarre
+broken
+builds
+bust
+changed
+chaotic
+confused
+converted
+corrupt
+crazy
+crooked
+damaged
+deranged
+designed
+destroyed
+different
+disorderly
+disturbed
+doctored
+drunk
+drunken
+dud
+erratic
+exotic
+fancy
+fix
+fixed
+fly
+free
+fresh
+funny
+in a mess
+in disarray
+in pieces
+in ruins
+jumbled
+loose
+mad
+madly
+mangled
+messy
+mixed
+moved
+muddled
+new
+novel
+odd
+off
+organised
+out
+out of order
+poor
+poorly
+rebuilt
+recast
+redone
+reformed
+remodelled
+reorganised
+reshaped
+revised
+rough
+ruined
+scrambled
+shaken
+shot
+shuffled
+sorted
+strange
+tangled
+twisted
+unruly
+unusual
+upset
+wild
+wildly
+wrecked
+wrong
+[reversal]
+about
+around
+back
+backed
+backing
+backwards
+brought back
+coming back
+going back
+in retreat
+over
+recalled
+reflected
+retiring
+retreating
+returned
+returning
+reversed
+rising
+round
+sent back
+taken back
+turned
+turned back
+turning
+up
+upset
+upwards
+west
+westward
+[hidden]
+amid
+among
+amongst
+buried in
+concealed
+concealed in
+contained in
+covers
+found in
+held by
+hidden
+hidden in
+hides
+holding
+in
+inside
+partly
+secreted
+some
+within
+[container]
+about
+around
+boxing
+captures
+clutching
+containing
+contains
+embraced by
+embracing
+entering
+holding
+holds
+housing
+in
+inside
+into
+keeping
+outside
+round
+swallowing
+takes in
+within
+without
+[deletion]
+beheaded
+cut
+cutting
+docked
+endless
+first off
+headless
+heartless
+lacking
+leaving
+less
+losing
+missing
+no
+not
+out
+short
+shortly
+tailless
+topless
+unfinished
+without
+[homophone]
+aloud
+announced
+broadcast
+by the sound of it
+heard
+in audition
+on the radio
+orally
+reportedly
+said
+say
+so to speak
+sound
+sounds like
+spoken
+they say
+we hear
+vocal
+voiced
+[initial]
+at first
+first
+first of
+firstly
+head
+heads
+initially
+lead
+leader
+leaders
+leading
+opening
+openings
+primarily
+starts
+to start with
+`
@@ -0,0 +1,124 @@
+package puzzle
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+// Format is a crossword file format.
+type Format string
+
+// supported formats
+const (
+	FormatPuz  Format = "puz"
+	FormatIPuz Format = "ipuz"
+	FormatJPZ  Format = "jpz"
+)
+
+// FormatForFile returns the format implied by the extension of the supplied file name.
+func FormatForFile(name string) (Format, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	switch Format(ext) {
+	case FormatPuz, FormatIPuz, FormatJPZ:
+		return Format(ext), nil
+	}
+	return "", inputerror.New(fmt.Sprintf("unknown puzzle format for %q, expected .puz, .ipuz or .jpz", name))
+}
+
+// DetectFormat guesses the format from the leading bytes of a file.
+func DetectFormat(data []byte) (Format, error) {
+	if len(data) >= 0x0E && bytes.HasPrefix(data[0x02:], []byte(puzMagic)) {
+		return FormatPuz, nil
+	}
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("ipuz(")):
+		return FormatIPuz, nil
+	case bytes.HasPrefix(trimmed, []byte("<")), bytes.HasPrefix(data, []byte("PK")):
+		return FormatJPZ, nil
+	}
+	return "", inputerror.New("unrecognized puzzle format")
+}
+
+// Read reads a puzzle in the supplied format, or detects the format from the content if it is empty.
+func Read(in io.Reader, format Format) (*Puzzle, error) {
+	br := bufio.NewReader(in)
+	if format == "" {
+		head, _ := br.Peek(64)
+		f, err := DetectFormat(head)
+		if err != nil {
+			return nil, err
+		}
+		format = f
+	}
+	switch format {
+	case FormatPuz:
+		return ReadPuz(br)
+	case FormatIPuz:
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "read ipuz")
+		}
+		return ReadIPuz(bytes.NewReader(stripIPuzWrapper(data)))
+	case FormatJPZ:
+		return ReadJPZ(br)
+	}
+	return nil, inputerror.New(fmt.Sprintf("unsupported puzzle format %q", format))
+}
+
+// stripIPuzWrapper removes the JSONP style ipuz(...) wrapper that some publishers use.
+func stripIPuzWrapper(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("ipuz(")) && bytes.HasSuffix(trimmed, []byte(")")) {
+		trimmed = trimmed[len("ipuz(") : len(trimmed)-1]
+	}
+	return trimmed
+}
+
+// Write writes the puzzle in the supplied format.
+func (p *Puzzle) Write(out io.Writer, format Format) error {
+	switch format {
+	case FormatPuz:
+		return p.WritePuz(out)
+	case FormatIPuz:
+		return p.WriteIPuz(out)
+	case FormatJPZ:
+		return p.WriteJPZ(out)
+	}
+	return inputerror.New(fmt.Sprintf("unsupported puzzle format %q", format))
+}
+
+// ReadFile reads a puzzle file, using its extension to determine the format.
+func ReadFile(name string) (*Puzzle, error) {
+	format, err := FormatForFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return Read(f, format)
+}
+
+// WriteFile writes the puzzle to a file, using its extension to determine the format.
+func (p *Puzzle) WriteFile(name string) error {
+	format, err := FormatForFile(name)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := p.Write(&buf, format); err != nil {
+		return err
+	}
+	return errors.Wrap(os.WriteFile(name, buf.Bytes(), 0644), "write puzzle")
+}
@@ -0,0 +1,106 @@
+package puzzle_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func richPuzzle() *puzzle.Puzzle {
+	p := testPuzzle()
+	p.Cell(1, 0).Fill = "H"
+	p.Cell(1, 1).Circled = true
+	p.Cell(2, 2).Shaded = true
+	p.Cell(2, 3).Solution = "IT"
+	p.Title = "Fish &amp; chips" // text that looks like markup is kept as is
+	p.Clues[0].Text = "Pet <& friend>"
+	p.Clues[1].Enumeration = "3,2"
+	return p
+}
+
+func assertSamePuzzle(t *testing.T, expected, actual *puzzle.Puzzle) {
+	assert.Equal(t, expected.Title, actual.Title)
+	assert.Equal(t, expected.Author, actual.Author)
+	assert.Equal(t, expected.Copyright, actual.Copyright)
+	assert.Equal(t, expected.Notes, actual.Notes)
+	assert.Equal(t, expected.Cells, actual.Cells)
+	assert.Equal(t, expected.Clues, actual.Clues)
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, format := range []puzzle.Format{puzzle.FormatIPuz, puzzle.FormatJPZ} {
+		t.Run(string(format), func(t *testing.T) {
+			p := richPuzzle()
+			var b bytes.Buffer
+			require.NoError(t, p.Write(&b, format))
+			p2, err := puzzle.Read(bytes.NewReader(b.Bytes()), "")
+			require.NoError(t, err)
+			assertSamePuzzle(t, p, p2)
+		})
+	}
+}
+
+func TestReadZippedJPZ(t *testing.T) {
+	var x bytes.Buffer
+	require.NoError(t, testPuzzle().WriteJPZ(&x))
+	var z bytes.Buffer
+	zw := zip.NewWriter(&z)
+	w, err := zw.Create("puzzle.xml")
+	require.NoError(t, err)
+	_, err = w.Write(x.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	p, err := puzzle.Read(bytes.NewReader(z.Bytes()), "")
+	require.NoError(t, err)
+	assertSamePuzzle(t, testPuzzle(), p)
+}
+
+func TestReadMalformed(t *testing.T) {
+	tests := []struct {
+		format puzzle.Format
+		input  string
+	}{
+		{puzzle.FormatIPuz, `{"kind":["http://ipuz.org/crossword#1"],"dimensions":{"width":2,"height":2},"puzzle":[[1,2]]}`},
+		{puzzle.FormatIPuz, `{"kind":["http://ipuz.org/sudoku#1"]}`},
+		{puzzle.FormatIPuz, `{not json`},
+		{puzzle.FormatJPZ, `<crossword-compiler-applet><rectangular-puzzle/></crossword-compiler-applet>`},
+		{puzzle.FormatJPZ, `<rectangular-puzzle><crossword><grid width="1" height="1"><cell x="2" y="1"/></grid></crossword></rectangular-puzzle>`},
+		{puzzle.FormatIPuz, `{"kind":["http://ipuz.org/crossword#1"],"dimensions":{"width":100000,"height":100000},"puzzle":[]}`},
+		{puzzle.FormatJPZ, `<rectangular-puzzle><crossword><grid width="100000" height="100000"/></crossword></rectangular-puzzle>`},
+	}
+	for _, test := range tests {
+		_, err := puzzle.Read(strings.NewReader(test.input), test.format)
+		require.Error(t, err, test.input)
+		assert.True(t, inputerror.IsInputError(err), test.input)
+	}
+}
+
+func TestReadLargeZippedJPZ(t *testing.T) {
+	var z bytes.Buffer
+	zw := zip.NewWriter(&z)
+	w, err := zw.Create("puzzle.xml")
+	require.NoError(t, err)
+	_, err = w.Write(bytes.Repeat([]byte(" "), 10<<20+1))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	_, err = puzzle.Read(bytes.NewReader(z.Bytes()), puzzle.FormatJPZ)
+	require.Error(t, err)
+	assert.True(t, inputerror.IsInputError(err))
+	assert.Contains(t, err.Error(), "larger than")
+}
+
+func TestFormatForFile(t *testing.T) {
+	f, err := puzzle.FormatForFile("daily.IPUZ")
+	require.NoError(t, err)
+	assert.Equal(t, puzzle.FormatIPuz, f)
+	_, err = puzzle.FormatForFile("daily.txt")
+	assert.True(t, inputerror.IsInputError(err))
+}
@@ -0,0 +1,76 @@
+package puzzle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+// ParseGrid reads a grid in the text format, one row per line with '#' for a block, '.' for an empty square
+// and a letter for a square that is already filled. Blank lines and lines starting with ';' are ignored.
+// Letters become the solution of their squares.
+func ParseGrid(r io.Reader) (*Puzzle, error) {
+	var rows []string
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		for _, ch := range line {
+			if ch != '#' && ch != '.' && !(ch >= 'a' && ch <= 'z') && !(ch >= 'A' && ch <= 'Z') {
+				return nil, inputerror.New(fmt.Sprintf("line %d: invalid grid character %q", lineNo, ch))
+			}
+		}
+		if len(rows) > 0 && len(line) != len(rows[0]) {
+			return nil, inputerror.New(fmt.Sprintf("line %d: row has %d squares, expected %d", lineNo, len(line), len(rows[0])))
+		}
+		rows = append(rows, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read grid")
+	}
+	if len(rows) == 0 {
+		return nil, inputerror.New("empty grid")
+	}
+	p := New(len(rows[0]), len(rows))
+	for r, row := range rows {
+		for c, ch := range row {
+			cell := p.Cell(r, c)
+			switch ch {
+			case '#':
+				cell.Block = true
+			case '.':
+			default:
+				cell.Solution = strings.ToUpper(string(ch))
+			}
+		}
+	}
+	return p, nil
+}
+
+// FormatGrid returns the solution of the grid in the text format.
+func FormatGrid(p *Puzzle) string {
+	var b strings.Builder
+	for r := 0; r < p.Height; r++ {
+		for c := 0; c < p.Width; c++ {
+			cell := p.Cell(r, c)
+			switch {
+			case cell.Block:
+				b.WriteByte('#')
+			case cell.Solution == "":
+				b.WriteByte('.')
+			default:
+				b.WriteString(cell.Solution[:1])
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,299 @@
+package puzzle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+const (
+	ipuzVersion = "http://ipuz.org/v2"
+	ipuzKind    = "http://ipuz.org/crossword#1"
+	ipuzBlock   = "#"
+)
+
+type ipuzDimensions struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type ipuzStyle struct {
+	Shapename string `json:"shapename,omitempty"`
+	Highlight bool   `json:"highlight,omitempty"`
+	Color     string `json:"color,omitempty"`
+}
+
+type ipuzCell struct {
+	Cell  interface{} `json:"cell"`
+	Style *ipuzStyle  `json:"style,omitempty"`
+}
+
+type ipuzClue struct {
+	Number      interface{} `json:"number"`
+	Clue        string      `json:"clue"`
+	Enumeration string      `json:"enumeration,omitempty"`
+}
+
+type ipuzFile struct {
+	Version    string                       `json:"version"`
+	Kind       []string                     `json:"kind"`
+	Title      string                       `json:"title,omitempty"`
+	Author     string                       `json:"author,omitempty"`
+	Copyright  string                       `json:"copyright,omitempty"`
+	Notes      string                       `json:"notes,omitempty"`
+	Dimensions ipuzDimensions               `json:"dimensions"`
+	Block      string                       `json:"block,omitempty"`
+	Empty      interface{}                  `json:"empty,omitempty"`
+	Puzzle     [][]json.RawMessage          `json:"puzzle"`
+	Solution   [][]json.RawMessage          `json:"solution,omitempty"`
+	Saved      [][]json.RawMessage          `json:"saved,omitempty"`
+	Clues      map[string][]json.RawMessage `json:"clues"`
+}
+
+func ipuzError(format string, args ...interface{}) error {
+	return inputerror.New("invalid ipuz file: " + fmt.Sprintf(format, args...))
+}
+
+// ipuzValue returns the string form of a scalar cell value, which may be a string, number or null.
+func ipuzValue(raw json.RawMessage) (string, bool, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", false, err
+	}
+	switch x := v.(type) {
+	case nil:
+		return "", true, nil
+	case string:
+		return x, false, nil
+	case float64:
+		return strconv.Itoa(int(x)), false, nil
+	case map[string]interface{}:
+		// cells with styles, or solutions with a value
+		for _, key := range []string{"cell", "value"} {
+			if inner, ok := x[key]; ok {
+				b, _ := json.Marshal(inner)
+				return ipuzValue(b)
+			}
+		}
+		return "", false, nil
+	}
+	return "", false, fmt.Errorf("unexpected value %s", string(raw))
+}
+
+// ReadIPuz reads a crossword in the ipuz JSON format.
+func ReadIPuz(in io.Reader) (*Puzzle, error) {
+	var f ipuzFile
+	dec := json.NewDecoder(in)
+	if err := dec.Decode(&f); err != nil {
+		return nil, ipuzError("%v", err)
+	}
+	isCrossword := false
+	for _, k := range f.Kind {
+		if strings.HasPrefix(k, "http://ipuz.org/crossword") {
+			isCrossword = true
+		}
+	}
+	if !isCrossword {
+		return nil, ipuzError("not a crossword")
+	}
+	w, h := f.Dimensions.Width, f.Dimensions.Height
+	if w <= 0 || h <= 0 || w > maxSize || h > maxSize {
+		return nil, ipuzError("invalid dimensions %dx%d", w, h)
+	}
+	if len(f.Puzzle) != h {
+		return nil, ipuzError("puzzle has %d rows, expected %d", len(f.Puzzle), h)
+	}
+	block := f.Block
+	if block == "" {
+		block = ipuzBlock
+	}
+	p := New(w, h)
+	p.Title, p.Author, p.Copyright, p.Notes = f.Title, f.Author, f.Copyright, f.Notes
+	for r, row := range f.Puzzle {
+		if len(row) != w {
+			return nil, ipuzError("puzzle row %d has %d cells, expected %d", r+1, len(row), w)
+		}
+		for c, raw := range row {
+			v, null, err := ipuzValue(raw)
+			if err != nil {
+				return nil, ipuzError("puzzle row %d: %v", r+1, err)
+			}
+			cell := p.Cell(r, c)
+			cell.Block = null || v == block
+			var styled ipuzCell
+			if json.Unmarshal(raw, &styled) == nil && styled.Style != nil {
+				cell.Circled = styled.Style.Shapename == "circle"
+				cell.Shaded = styled.Style.Highlight || styled.Style.Color != ""
+			}
+		}
+	}
+	grids := map[string][][]json.RawMessage{"solution": f.Solution, "saved": f.Saved}
+	for name, grid := range grids {
+		if grid == nil {
+			continue
+		}
+		if len(grid) != h {
+			return nil, ipuzError("%s has %d rows, expected %d", name, len(grid), h)
+		}
+		for r, row := range grid {
+			if len(row) != w {
+				return nil, ipuzError("%s row %d has %d cells, expected %d", name, r+1, len(row), w)
+			}
+			for c, raw := range row {
+				v, _, err := ipuzValue(raw)
+				if err != nil {
+					return nil, ipuzError("%s row %d: %v", name, r+1, err)
+				}
+				cell := p.Cell(r, c)
+				if cell.Block || v == block || v == "0" {
+					continue
+				}
+				if name == "solution" {
+					cell.Solution = strings.ToUpper(v)
+				} else {
+					cell.Fill = strings.ToUpper(v)
+				}
+			}
+		}
+	}
+	for key, list := range f.Clues {
+		var dir Direction
+		switch strings.ToLower(strings.SplitN(key, ":", 2)[0]) {
+		case "across":
+			dir = Across
+		case "down":
+			dir = Down
+		default:
+			continue
+		}
+		for _, raw := range list {
+			c, err := parseIPuzClue(raw)
+			if err != nil {
+				return nil, ipuzError("%s clue: %v", key, err)
+			}
+			c.Direction = dir
+			p.Clues = append(p.Clues, c)
+		}
+	}
+	sortClues(p.Clues)
+	return p, nil
+}
+
+func parseIPuzClue(raw json.RawMessage) (*Clue, error) {
+	var pair []json.RawMessage
+	var obj ipuzClue
+	if err := json.Unmarshal(raw, &pair); err == nil {
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("expected number and text, got %s", string(raw))
+		}
+		if err := json.Unmarshal(pair[1], &obj.Clue); err != nil {
+			return nil, err
+		}
+		obj.Number = pair[0]
+	} else if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	var num string
+	switch n := obj.Number.(type) {
+	case json.RawMessage:
+		v, _, err := ipuzValue(n)
+		if err != nil {
+			return nil, err
+		}
+		num = v
+	case float64:
+		num = strconv.Itoa(int(n))
+	case string:
+		num = n
+	}
+	number, err := strconv.Atoi(num)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clue number %q", num)
+	}
+	return &Clue{Number: number, Text: obj.Clue, Enumeration: obj.Enumeration}, nil
+}
+
+// WriteIPuz writes the puzzle in the ipuz JSON format.
+func (p *Puzzle) WriteIPuz(out io.Writer) error {
+	numbers := p.Numbers()
+	f := map[string]interface{}{
+		"version":    ipuzVersion,
+		"kind":       []string{ipuzKind},
+		"dimensions": ipuzDimensions{Width: p.Width, Height: p.Height},
+		"block":      ipuzBlock,
+		"empty":      0,
+	}
+	for key, val := range map[string]string{"title": p.Title, "author": p.Author, "copyright": p.Copyright, "notes": p.Notes} {
+		if val != "" {
+			f[key] = val
+		}
+	}
+	var grid, solution, saved [][]interface{}
+	hasSolution, hasSaved := false, false
+	for r := 0; r < p.Height; r++ {
+		var gridRow, solRow, savedRow []interface{}
+		for c := 0; c < p.Width; c++ {
+			cell := p.Cell(r, c)
+			if cell.Block {
+				gridRow = append(gridRow, ipuzBlock)
+				solRow = append(solRow, ipuzBlock)
+				savedRow = append(savedRow, ipuzBlock)
+				continue
+			}
+			var v interface{} = numbers[r*p.Width+c]
+			if cell.Circled || cell.Shaded {
+				style := &ipuzStyle{Highlight: cell.Shaded}
+				if cell.Circled {
+					style.Shapename = "circle"
+				}
+				v = ipuzCell{Cell: v, Style: style}
+			}
+			gridRow = append(gridRow, v)
+			solRow = append(solRow, nilIfEmpty(cell.Solution))
+			savedRow = append(savedRow, nilIfEmpty(cell.Fill))
+			hasSolution = hasSolution || cell.Solution != ""
+			hasSaved = hasSaved || cell.Fill != ""
+		}
+		grid = append(grid, gridRow)
+		solution = append(solution, solRow)
+		saved = append(saved, savedRow)
+	}
+	f["puzzle"] = grid
+	if hasSolution {
+		f["solution"] = solution
+	}
+	if hasSaved {
+		f["saved"] = saved
+	}
+	clues := map[string][]interface{}{}
+	for _, c := range p.Clues {
+		key := "Across"
+		if c.Direction == Down {
+			key = "Down"
+		}
+		if c.Enumeration != "" {
+			clues[key] = append(clues[key], ipuzClue{Number: c.Number, Clue: c.Text, Enumeration: c.Enumeration})
+		} else {
+			clues[key] = append(clues[key], []interface{}{c.Number, c.Text})
+		}
+	}
+	f["clues"] = clues
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal ipuz")
+	}
+	_, err = out.Write(append(b, '\n'))
+	return err
+}
+
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,308 @@
+package puzzle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxJPZSize  = 10 << 20 // maximum size of the XML in a zipped JPZ file
+	jpzAppletNS = "http://crossword.info/xml/crossword-compiler-applet"
+	jpzPuzzleNS = "http://crossword.info/xml/rectangular-puzzle"
+)
+
+type jpzCell struct {
+	X               int    `xml:"x,attr"`
+	Y               int    `xml:"y,attr"`
+	Type            string `xml:"type,attr,omitempty"`
+	Solution        string `xml:"solution,attr,omitempty"`
+	Number          string `xml:"number,attr,omitempty"`
+	SolveState      string `xml:"solve-state,attr,omitempty"`
+	BackgroundShape string `xml:"background-shape,attr,omitempty"`
+	BackgroundColor string `xml:"background-color,attr,omitempty"`
+}
+
+type jpzWord struct {
+	ID string `xml:"id,attr"`
+	X  string `xml:"x,attr"`
+	Y  string `xml:"y,attr"`
+}
+
+type jpzClue struct {
+	Word   string `xml:"word,attr"`
+	Number string `xml:"number,attr"`
+	Format string `xml:"format,attr,omitempty"`
+	Text   string `xml:",innerxml"`
+}
+
+type jpzClues struct {
+	Title struct {
+		Text string `xml:",innerxml"`
+	} `xml:"title"`
+	Clues []jpzClue `xml:"clue"`
+}
+
+type jpzPuzzle struct {
+	Metadata struct {
+		Title       string `xml:"title"`
+		Creator     string `xml:"creator"`
+		Copyright   string `xml:"copyright"`
+		Description string `xml:"description"`
+	} `xml:"metadata"`
+	Crossword *struct {
+		Grid struct {
+			Width  int       `xml:"width,attr"`
+			Height int       `xml:"height,attr"`
+			Cells  []jpzCell `xml:"cell"`
+		} `xml:"grid"`
+		Words []jpzWord  `xml:"word"`
+		Clues []jpzClues `xml:"clues"`
+	} `xml:"crossword"`
+}
+
+type jpzFile struct {
+	Puzzle *jpzPuzzle `xml:"rectangular-puzzle"`
+}
+
+var jpzTags = regexp.MustCompile(`<[^>]*>`)
+
+// jpzText returns the plain text of inner XML that may contain formatting markup.
+func jpzText(s string) string {
+	return strings.TrimSpace(html.UnescapeString(jpzTags.ReplaceAllString(s, "")))
+}
+
+func jpzError(format string, args ...interface{}) error {
+	return inputerror.New("invalid jpz file: " + fmt.Sprintf(format, args...))
+}
+
+// ReadJPZ reads a crossword in the Crossword Compiler JPZ format, which may be plain XML or zipped.
+func ReadJPZ(in io.Reader) (*Puzzle, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, errors.Wrap(err, "read jpz")
+	}
+	if bytes.HasPrefix(data, []byte("PK")) {
+		if data, err = unzipJPZ(data); err != nil {
+			return nil, err
+		}
+	}
+	var f jpzFile
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, jpzError("%v", err)
+	}
+	if f.Puzzle == nil {
+		// some files omit the applet wrapper
+		var rp jpzPuzzle
+		if err := xml.Unmarshal(data, &rp); err == nil && rp.Crossword != nil {
+			f.Puzzle = &rp
+		}
+	}
+	if f.Puzzle == nil || f.Puzzle.Crossword == nil {
+		return nil, jpzError("no crossword found")
+	}
+	cw := f.Puzzle.Crossword
+	w, h := cw.Grid.Width, cw.Grid.Height
+	if w <= 0 || h <= 0 || w > maxSize || h > maxSize {
+		return nil, jpzError("invalid dimensions %dx%d", w, h)
+	}
+	p := New(w, h)
+	// metadata is plain text that the XML decoder has already unescaped
+	p.Title = strings.TrimSpace(f.Puzzle.Metadata.Title)
+	p.Author = strings.TrimSpace(f.Puzzle.Metadata.Creator)
+	p.Copyright = strings.TrimSpace(f.Puzzle.Metadata.Copyright)
+	p.Notes = strings.TrimSpace(f.Puzzle.Metadata.Description)
+	seen := make([]bool, w*h)
+	for _, jc := range cw.Grid.Cells {
+		cell := p.Cell(jc.Y-1, jc.X-1)
+		if cell == nil {
+			return nil, jpzError("cell (%d,%d) outside %dx%d grid", jc.X, jc.Y, w, h)
+		}
+		seen[(jc.Y-1)*w+jc.X-1] = true
+		if jc.Type == "block" || jc.Type == "void" {
+			cell.Block = true
+			continue
+		}
+		cell.Solution = strings.ToUpper(jc.Solution)
+		cell.Fill = strings.ToUpper(jc.SolveState)
+		cell.Circled = jc.BackgroundShape == "circle"
+		cell.Shaded = jc.BackgroundColor != "" && !strings.EqualFold(jc.BackgroundColor, "#ffffff")
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, jpzError("missing cell (%d,%d)", i%w+1, i/w+1)
+		}
+	}
+
+	words := map[string]jpzWord{}
+	for _, wd := range cw.Words {
+		words[wd.ID] = wd
+	}
+	for _, list := range cw.Clues {
+		title := strings.ToLower(jpzText(list.Title.Text))
+		for _, jc := range list.Clues {
+			number, err := strconv.Atoi(jc.Number)
+			if err != nil {
+				return nil, jpzError("invalid clue number %q", jc.Number)
+			}
+			var dir Direction
+			switch wd, ok := words[jc.Word]; {
+			case ok && strings.Contains(wd.X, "-"):
+				dir = Across
+			case ok && strings.Contains(wd.Y, "-"):
+				dir = Down
+			case strings.Contains(title, "across"):
+				dir = Across
+			case strings.Contains(title, "down"):
+				dir = Down
+			default:
+				return nil, jpzError("cannot determine direction of clue %d", number)
+			}
+			p.Clues = append(p.Clues, &Clue{Number: number, Direction: dir, Text: jpzText(jc.Text), Enumeration: jc.Format})
+		}
+	}
+	sortClues(p.Clues)
+	return p, nil
+}
+
+func unzipJPZ(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, jpzError("%v", err)
+	}
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, jpzError("%v", err)
+		}
+		defer func() { _ = rc.Close() }()
+		data, err := io.ReadAll(io.LimitReader(rc, maxJPZSize+1))
+		if err != nil {
+			return nil, jpzError("%v", err)
+		}
+		if len(data) > maxJPZSize {
+			return nil, jpzError("puzzle is larger than %d bytes", maxJPZSize)
+		}
+		return data, nil
+	}
+	return nil, jpzError("empty zip archive")
+}
+
+type jpzOutClues struct {
+	Ordering string    `xml:"ordering,attr"`
+	Title    string    `xml:"title>b"`
+	Clues    []jpzClue `xml:"clue"`
+}
+
+type jpzOut struct {
+	XMLName xml.Name `xml:"crossword-compiler-applet"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Puzzle  struct {
+		XMLNS    string `xml:"xmlns,attr"`
+		Metadata struct {
+			Title       string `xml:"title,omitempty"`
+			Creator     string `xml:"creator,omitempty"`
+			Copyright   string `xml:"copyright,omitempty"`
+			Description string `xml:"description,omitempty"`
+		} `xml:"metadata"`
+		Crossword struct {
+			Grid struct {
+				Width  int       `xml:"width,attr"`
+				Height int       `xml:"height,attr"`
+				Cells  []jpzCell `xml:"cell"`
+			} `xml:"grid"`
+			Words []jpzWord     `xml:"word"`
+			Clues []jpzOutClues `xml:"clues"`
+		} `xml:"crossword"`
+	} `xml:"rectangular-puzzle"`
+}
+
+// WriteJPZ writes the puzzle as uncompressed JPZ XML.
+func (p *Puzzle) WriteJPZ(out io.Writer) error {
+	var f jpzOut
+	f.XMLNS = jpzAppletNS
+	f.Puzzle.XMLNS = jpzPuzzleNS
+	f.Puzzle.Metadata.Title = p.Title
+	f.Puzzle.Metadata.Creator = p.Author
+	f.Puzzle.Metadata.Copyright = p.Copyright
+	f.Puzzle.Metadata.Description = p.Notes
+	cw := &f.Puzzle.Crossword
+	cw.Grid.Width, cw.Grid.Height = p.Width, p.Height
+	numbers := p.Numbers()
+	for r := 0; r < p.Height; r++ {
+		for c := 0; c < p.Width; c++ {
+			cell := p.Cell(r, c)
+			jc := jpzCell{X: c + 1, Y: r + 1}
+			if cell.Block {
+				jc.Type = "block"
+			} else {
+				jc.Solution = cell.Solution
+				jc.SolveState = cell.Fill
+				if n := numbers[r*p.Width+c]; n > 0 {
+					jc.Number = strconv.Itoa(n)
+				}
+				if cell.Circled {
+					jc.BackgroundShape = "circle"
+				}
+				if cell.Shaded {
+					jc.BackgroundColor = "#CCCCCC"
+				}
+			}
+			cw.Grid.Cells = append(cw.Grid.Cells, jc)
+		}
+	}
+
+	across := jpzOutClues{Ordering: "normal", Title: "Across"}
+	down := jpzOutClues{Ordering: "normal", Title: "Down"}
+	clues := map[string]*Clue{}
+	for _, c := range p.Clues {
+		clues[c.Label()] = c
+	}
+	for i, s := range p.Slots() {
+		id := strconv.Itoa(i + 1)
+		wd := jpzWord{ID: id, X: strconv.Itoa(s.Col + 1), Y: strconv.Itoa(s.Row + 1)}
+		if s.Direction == Across {
+			wd.X = fmt.Sprintf("%d-%d", s.Col+1, s.Col+s.Length)
+		} else {
+			wd.Y = fmt.Sprintf("%d-%d", s.Row+1, s.Row+s.Length)
+		}
+		cw.Words = append(cw.Words, wd)
+		c := clues[s.Label()]
+		if c == nil {
+			continue
+		}
+		var text bytes.Buffer
+		if err := xml.EscapeText(&text, []byte(c.Text)); err != nil {
+			return errors.Wrap(err, "escape clue")
+		}
+		jc := jpzClue{Word: id, Number: strconv.Itoa(s.Number), Format: c.Enumeration, Text: text.String()}
+		if s.Direction == Across {
+			across.Clues = append(across.Clues, jc)
+		} else {
+			down.Clues = append(down.Clues, jc)
+		}
+	}
+	cw.Clues = []jpzOutClues{across, down}
+
+	b, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal jpz")
+	}
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	_, err = out.Write(append(b, '\n'))
+	return err
+}
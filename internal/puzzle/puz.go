@@ -0,0 +1,642 @@
+package puzzle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/pkg/errors"
+)
+
+const (
+	puzMagic        = "ACROSS&DOWN\x00"
+	puzHeaderSize   = 0x34
+	puzMagicOffset  = 0x02
+	puzCIBOffset    = 0x2C
+	puzEmptyFill    = '-'
+	puzBlock        = '.'
+	puzScrambledTag = 0x0004
+	puzCircleFlag   = 0x80
+	defaultVersion  = "1.3"
+	defaultPuzzleID = 0x0001
+)
+
+// puzExtras are the parts of a .puz file that have no place in the puzzle model but are preserved
+// so that files round-trip faithfully.
+type puzExtras struct {
+	version           string
+	reserved1C        uint16
+	reserved20        [12]byte
+	puzzleType        uint16
+	scrambledSolution []byte // solution as stored in a file that is still scrambled
+	scrambledChecksum uint16
+	gextFlags         []byte            // GEXT flags other than circles
+	sections          map[string][]byte // sections that are not interpreted, such as LTIM
+	sectionOrder      []string
+}
+
+func (p *Puzzle) extras() *puzExtras {
+	if p.puz == nil {
+		p.puz = &puzExtras{version: defaultVersion, puzzleType: defaultPuzzleID}
+	}
+	return p.puz
+}
+
+func checksum(data []byte, sum uint16) uint16 {
+	for _, b := range data {
+		if sum&1 != 0 {
+			sum = (sum >> 1) | 0x8000
+		} else {
+			sum >>= 1
+		}
+		sum += uint16(b)
+	}
+	return sum
+}
+
+func fromLatin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func toLatin1(s string) []byte {
+	var ret []byte
+	for _, r := range s {
+		if r > 0xff {
+			r = '?'
+		}
+		ret = append(ret, byte(r))
+	}
+	return ret
+}
+
+type puzFile struct {
+	header    []byte
+	solution  []byte
+	fill      []byte
+	title     []byte
+	author    []byte
+	copyright []byte
+	clues     [][]byte
+	notes     []byte
+}
+
+func (f *puzFile) textChecksum(sum uint16, version string) uint16 {
+	for _, s := range [][]byte{f.title, f.author, f.copyright} {
+		if len(s) > 0 {
+			sum = checksum(append(append([]byte{}, s...), 0), sum)
+		}
+	}
+	for _, c := range f.clues {
+		sum = checksum(c, sum)
+	}
+	if len(f.notes) > 0 && version >= "1.3" {
+		sum = checksum(append(append([]byte{}, f.notes...), 0), sum)
+	}
+	return sum
+}
+
+func (f *puzFile) checksums(version string) (cib, overall uint16, masked [8]byte) {
+	cib = checksum(f.header[puzCIBOffset:puzCIBOffset+8], 0)
+	overall = checksum(f.solution, cib)
+	overall = checksum(f.fill, overall)
+	overall = f.textChecksum(overall, version)
+
+	sol := checksum(f.solution, 0)
+	fill := checksum(f.fill, 0)
+	text := f.textChecksum(0, version)
+	sums := []uint16{cib, sol, fill, text}
+	for i, sum := range sums {
+		masked[i] = "ICHE"[i] ^ byte(sum&0xff)
+		masked[i+4] = "ATED"[i] ^ byte(sum>>8)
+	}
+	return cib, overall, masked
+}
+
+type puzReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *puzReader) bytes(n int) ([]byte, error) {
+	if r.pos+n > len(r.data) {
+		return nil, inputerror.New("unexpected end of .puz file")
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *puzReader) str() ([]byte, error) {
+	end := bytes.IndexByte(r.data[r.pos:], 0)
+	if end < 0 {
+		return nil, inputerror.New("unterminated string in .puz file")
+	}
+	s := r.data[r.pos : r.pos+end]
+	r.pos += end + 1
+	return s, nil
+}
+
+// ReadPuz reads a puzzle in the Across Lite .puz format.
+func ReadPuz(in io.Reader) (*Puzzle, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, errors.Wrap(err, "read .puz file")
+	}
+	start := bytes.Index(data, []byte(puzMagic))
+	if start < puzMagicOffset {
+		return nil, inputerror.New("not a .puz file")
+	}
+	r := &puzReader{data: data, pos: start - puzMagicOffset}
+	var f puzFile
+	if f.header, err = r.bytes(puzHeaderSize); err != nil {
+		return nil, err
+	}
+	h := f.header
+	le := binary.LittleEndian
+	width, height := int(h[0x2C]), int(h[0x2D])
+	numClues := int(le.Uint16(h[0x2E:]))
+	if width == 0 || height == 0 {
+		return nil, inputerror.New("invalid .puz grid size")
+	}
+	if f.solution, err = r.bytes(width * height); err != nil {
+		return nil, err
+	}
+	if f.fill, err = r.bytes(width * height); err != nil {
+		return nil, err
+	}
+	for _, s := range []*[]byte{&f.title, &f.author, &f.copyright} {
+		if *s, err = r.str(); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < numClues; i++ {
+		c, err := r.str()
+		if err != nil {
+			return nil, err
+		}
+		f.clues = append(f.clues, c)
+	}
+	// notes are missing from some old files
+	if r.pos < len(r.data) {
+		if f.notes, err = r.str(); err != nil {
+			return nil, err
+		}
+	}
+
+	p := New(width, height)
+	x := p.extras()
+	x.version = strings.TrimRight(string(h[0x18:0x1C]), "\x00")
+	x.reserved1C = le.Uint16(h[0x1C:])
+	copy(x.reserved20[:], h[0x20:0x2C])
+	x.puzzleType = le.Uint16(h[0x30:])
+	cib, overall, _ := f.checksums(x.version)
+	if cib != le.Uint16(h[0x0E:]) {
+		return nil, inputerror.New("bad header checksum in .puz file")
+	}
+	if overall != le.Uint16(h[0x00:]) {
+		return nil, inputerror.New("bad checksum in .puz file")
+	}
+
+	p.Title, p.Author, p.Copyright = fromLatin1(f.title), fromLatin1(f.author), fromLatin1(f.copyright)
+	p.Notes = fromLatin1(f.notes)
+	scrambled := le.Uint16(h[0x32:])&puzScrambledTag != 0
+	for i, c := range p.Cells {
+		if f.solution[i] == puzBlock {
+			c.Block = true
+			continue
+		}
+		if !scrambled {
+			c.Solution = string(f.solution[i])
+		}
+		if f.fill[i] != puzEmptyFill && f.fill[i] != puzBlock {
+			c.Fill = string(f.fill[i])
+		}
+	}
+	if scrambled {
+		x.scrambledSolution = append([]byte{}, f.solution...)
+		x.scrambledChecksum = le.Uint16(h[0x1E:])
+	}
+	if err := p.readSections(r); err != nil {
+		return nil, err
+	}
+
+	slots := p.Slots()
+	if len(slots) != len(f.clues) {
+		return nil, inputerror.New(fmt.Sprintf(".puz file has %d clues for %d slots", len(f.clues), len(slots)))
+	}
+	for i, s := range slots {
+		p.Clues = append(p.Clues, &Clue{Number: s.Number, Direction: s.Direction, Text: fromLatin1(f.clues[i])})
+	}
+	return p, nil
+}
+
+func (p *Puzzle) readSections(r *puzReader) error {
+	x := p.extras()
+	var rebusGrid []byte
+	rebusTable := map[int]string{}
+	for r.pos+8 <= len(r.data) {
+		head, _ := r.bytes(8)
+		name := string(head[:4])
+		length := int(binary.LittleEndian.Uint16(head[4:]))
+		data, err := r.bytes(length)
+		if err != nil {
+			return err
+		}
+		if checksum(data, 0) != binary.LittleEndian.Uint16(head[6:]) {
+			return inputerror.New(fmt.Sprintf("bad checksum for section %s in .puz file", name))
+		}
+		if _, err := r.bytes(1); err != nil {
+			return err
+		}
+		switch name {
+		case "GRBS":
+			rebusGrid = data
+		case "RTBL":
+			for _, entry := range strings.Split(fromLatin1(data), ";") {
+				parts := strings.SplitN(entry, ":", 2)
+				if len(parts) != 2 {
+					continue
+				}
+				n, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+				if err != nil {
+					return inputerror.New(fmt.Sprintf("invalid rebus table entry %q in .puz file", entry))
+				}
+				rebusTable[n] = parts[1]
+			}
+		case "GEXT":
+			if len(data) != len(p.Cells) {
+				return inputerror.New("invalid GEXT section in .puz file")
+			}
+			x.gextFlags = make([]byte, len(data))
+			for i, b := range data {
+				p.Cells[i].Circled = b&puzCircleFlag != 0
+				x.gextFlags[i] = b &^ puzCircleFlag
+			}
+		case "RUSR":
+			rr := &puzReader{data: data}
+			for _, c := range p.Cells {
+				s, err := rr.str()
+				if err != nil {
+					return err
+				}
+				if len(s) > 0 {
+					c.Fill = fromLatin1(s)
+				}
+			}
+		default:
+			if x.sections == nil {
+				x.sections = map[string][]byte{}
+			}
+			x.sections[name] = append([]byte{}, data...)
+			x.sectionOrder = append(x.sectionOrder, name)
+		}
+	}
+	if len(rebusGrid) == len(p.Cells) && x.scrambledSolution == nil {
+		for i, b := range rebusGrid {
+			if b == 0 {
+				continue
+			}
+			sol, ok := rebusTable[int(b)-1]
+			if !ok {
+				return inputerror.New(fmt.Sprintf("missing rebus table entry %d in .puz file", int(b)-1))
+			}
+			p.Cells[i].Solution = sol
+		}
+	}
+	return nil
+}
+
+func writeSection(w *bytes.Buffer, name string, data []byte) {
+	head := make([]byte, 8)
+	copy(head, name)
+	binary.LittleEndian.PutUint16(head[4:], uint16(len(data)))
+	binary.LittleEndian.PutUint16(head[6:], checksum(data, 0))
+	w.Write(head)
+	w.Write(data)
+	w.WriteByte(0)
+}
+
+// WritePuz writes the puzzle in the Across Lite .puz format.
+func (p *Puzzle) WritePuz(out io.Writer) error {
+	if p.Width > maxSize || p.Height > maxSize || p.Width*p.Height != len(p.Cells) {
+		return inputerror.New("puzzle cannot be written as a .puz file: invalid grid size")
+	}
+	x := p.extras()
+	scrambled := x.scrambledSolution != nil && len(x.scrambledSolution) == len(p.Cells)
+	if !scrambled {
+		for i, c := range p.Cells {
+			if !c.Block && c.Solution == "" {
+				return inputerror.New(fmt.Sprintf("puzzle cannot be written as a .puz file: no solution for row %d, column %d",
+					i/p.Width+1, i%p.Width+1))
+			}
+		}
+	}
+	slots := p.Slots()
+	clues := map[string]*Clue{}
+	for _, c := range p.Clues {
+		clues[c.Label()] = c
+	}
+	f := puzFile{
+		header:    make([]byte, puzHeaderSize),
+		solution:  make([]byte, len(p.Cells)),
+		fill:      make([]byte, len(p.Cells)),
+		title:     toLatin1(p.Title),
+		author:    toLatin1(p.Author),
+		copyright: toLatin1(p.Copyright),
+		notes:     toLatin1(p.Notes),
+	}
+	for _, s := range slots {
+		text := ""
+		if c := clues[s.Label()]; c != nil {
+			text = c.FullText()
+		}
+		f.clues = append(f.clues, toLatin1(text))
+	}
+	var rebusGrid []byte
+	var rebusKeys []string
+	rebusIndex := map[string]int{}
+	userRebus := false
+	for i, c := range p.Cells {
+		if c.Block {
+			f.solution[i], f.fill[i] = puzBlock, puzBlock
+			continue
+		}
+		if c.Solution != "" {
+			f.solution[i] = strings.ToUpper(c.Solution)[0]
+		}
+		if len(c.Solution) > 1 {
+			if rebusGrid == nil {
+				rebusGrid = make([]byte, len(p.Cells))
+			}
+			key := strings.ToUpper(c.Solution)
+			if _, ok := rebusIndex[key]; !ok {
+				rebusIndex[key] = len(rebusKeys)
+				rebusKeys = append(rebusKeys, key)
+			}
+			rebusGrid[i] = byte(rebusIndex[key] + 1)
+		}
+		f.fill[i] = puzEmptyFill
+		if c.Fill != "" {
+			f.fill[i] = strings.ToUpper(c.Fill)[0]
+			userRebus = userRebus || len(c.Fill) > 1
+		}
+	}
+	if scrambled {
+		copy(f.solution, x.scrambledSolution)
+	}
+
+	h := f.header
+	le := binary.LittleEndian
+	copy(h[puzMagicOffset:], puzMagic)
+	copy(h[0x18:0x1C], x.version)
+	le.PutUint16(h[0x1C:], x.reserved1C)
+	copy(h[0x20:0x2C], x.reserved20[:])
+	h[0x2C], h[0x2D] = byte(p.Width), byte(p.Height)
+	le.PutUint16(h[0x2E:], uint16(len(f.clues)))
+	le.PutUint16(h[0x30:], x.puzzleType)
+	if scrambled {
+		le.PutUint16(h[0x32:], puzScrambledTag)
+		le.PutUint16(h[0x1E:], x.scrambledChecksum)
+	}
+	cib, overall, masked := f.checksums(x.version)
+	le.PutUint16(h[0x00:], overall)
+	le.PutUint16(h[0x0E:], cib)
+	copy(h[0x10:0x18], masked[:])
+
+	var b bytes.Buffer
+	b.Write(h)
+	b.Write(f.solution)
+	b.Write(f.fill)
+	for _, s := range [][]byte{f.title, f.author, f.copyright} {
+		b.Write(s)
+		b.WriteByte(0)
+	}
+	for _, c := range f.clues {
+		b.Write(c)
+		b.WriteByte(0)
+	}
+	b.Write(f.notes)
+	b.WriteByte(0)
+
+	if rebusGrid != nil && !scrambled {
+		writeSection(&b, "GRBS", rebusGrid)
+		var table strings.Builder
+		for i, k := range rebusKeys {
+			fmt.Fprintf(&table, "%2d:%s;", i, k)
+		}
+		writeSection(&b, "RTBL", toLatin1(table.String()))
+	}
+	if data, ok := x.sections["LTIM"]; ok {
+		writeSection(&b, "LTIM", data)
+	}
+	gext := make([]byte, len(p.Cells))
+	hasGext := false
+	for i, c := range p.Cells {
+		if len(x.gextFlags) == len(p.Cells) {
+			gext[i] = x.gextFlags[i]
+		}
+		if c.Circled {
+			gext[i] |= puzCircleFlag
+		}
+		hasGext = hasGext || gext[i] != 0
+	}
+	if hasGext {
+		writeSection(&b, "GEXT", gext)
+	}
+	if userRebus {
+		var rusr bytes.Buffer
+		for _, c := range p.Cells {
+			if len(c.Fill) > 1 {
+				rusr.Write(toLatin1(strings.ToUpper(c.Fill)))
+			}
+			rusr.WriteByte(0)
+		}
+		writeSection(&b, "RUSR", rusr.Bytes())
+	}
+	for _, name := range x.sectionOrder {
+		if name != "LTIM" {
+			writeSection(&b, name, x.sections[name])
+		}
+	}
+	_, err := out.Write(b.Bytes())
+	return err
+}
+
+// Scrambled returns true if the solution of the puzzle is scrambled and therefore unavailable.
+func (p *Puzzle) Scrambled() bool {
+	return p.puz != nil && p.puz.scrambledSolution != nil
+}
+
+// solutionLetters returns the solution letters in column-major order, skipping blocks.
+func (p *Puzzle) solutionLetters(solution []byte) []byte {
+	var ret []byte
+	for c := 0; c < p.Width; c++ {
+		for r := 0; r < p.Height; r++ {
+			if b := solution[r*p.Width+c]; b != puzBlock {
+				ret = append(ret, b)
+			}
+		}
+	}
+	return ret
+}
+
+func (p *Puzzle) setSolutionLetters(solution []byte, letters []byte) {
+	i := 0
+	for c := 0; c < p.Width; c++ {
+		for r := 0; r < p.Height; r++ {
+			if solution[r*p.Width+c] != puzBlock {
+				solution[r*p.Width+c] = letters[i]
+				i++
+			}
+		}
+	}
+}
+
+func keyDigits(key int) ([4]int, error) {
+	var d [4]int
+	if key < 1000 || key > 9999 {
+		return d, inputerror.New(fmt.Sprintf("invalid key %d, keys have four digits", key))
+	}
+	for i := 3; i >= 0; i-- {
+		d[i] = key % 10
+		key /= 10
+	}
+	return d, nil
+}
+
+func scramble(s []byte, key [4]int) []byte {
+	s = append([]byte{}, s...)
+	for _, k := range key {
+		for i := range s {
+			s[i] = 'A' + (s[i]-'A'+byte(key[i%4]))%26
+		}
+		s = append(append([]byte{}, s[k:]...), s[:k]...)
+		mid := len(s) / 2
+		shuffled := make([]byte, 0, len(s))
+		for i := 0; i < mid; i++ {
+			shuffled = append(shuffled, s[mid+i], s[i])
+		}
+		if len(s)%2 != 0 {
+			shuffled = append(shuffled, s[len(s)-1])
+		}
+		s = shuffled
+	}
+	return s
+}
+
+func unscramble(s []byte, key [4]int) []byte {
+	s = append([]byte{}, s...)
+	n := len(s)
+	for i := 3; i >= 0; i-- {
+		k := key[i]
+		var odd, even []byte
+		for j := range s {
+			if j%2 == 1 {
+				odd = append(odd, s[j])
+			} else {
+				even = append(even, s[j])
+			}
+		}
+		s = append(odd, even...)
+		s = append(append([]byte{}, s[n-k:]...), s[:n-k]...)
+		for j := range s {
+			s[j] = 'A' + (s[j]-'A'+26-byte(key[j%4]))%26
+		}
+	}
+	return s
+}
+
+func lettersOnly(s []byte) bool {
+	for _, b := range s {
+		if b < 'A' || b > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Unscramble unscrambles the solution of a scrambled puzzle using its four digit key.
+func (p *Puzzle) Unscramble(key int) error {
+	if !p.Scrambled() {
+		return inputerror.New("puzzle is not scrambled")
+	}
+	digits, err := keyDigits(key)
+	if err != nil {
+		return err
+	}
+	x := p.puz
+	letters := p.solutionLetters(x.scrambledSolution)
+	if len(letters) < 12 || !lettersOnly(letters) {
+		return inputerror.New("scrambled solution cannot be unscrambled")
+	}
+	plain := unscramble(letters, digits)
+	if checksum(plain, 0) != x.scrambledChecksum || !lettersOnly(plain) {
+		return inputerror.New(fmt.Sprintf("incorrect key %d", key))
+	}
+	solution := append([]byte{}, x.scrambledSolution...)
+	p.setSolutionLetters(solution, plain)
+	for i, c := range p.Cells {
+		if !c.Block {
+			c.Solution = string(solution[i])
+		}
+	}
+	x.scrambledSolution = nil
+	x.scrambledChecksum = 0
+	return nil
+}
+
+// FindKey tries every four digit key and returns the first one that unscrambles the puzzle.
+func (p *Puzzle) FindKey() (int, error) {
+	if !p.Scrambled() {
+		return 0, inputerror.New("puzzle is not scrambled")
+	}
+	for key := 1000; key <= 9999; key++ {
+		if err := p.Unscramble(key); err == nil {
+			return key, nil
+		}
+	}
+	return 0, inputerror.New("no key unscrambles the puzzle")
+}
+
+// Scramble scrambles the solution using the supplied four digit key so that it is hidden when the
+// puzzle is written as a .puz file. Rebus squares keep only their first letter.
+func (p *Puzzle) Scramble(key int) error {
+	if p.Scrambled() {
+		return inputerror.New("puzzle is already scrambled")
+	}
+	digits, err := keyDigits(key)
+	if err != nil {
+		return err
+	}
+	solution := make([]byte, len(p.Cells))
+	for i, c := range p.Cells {
+		switch {
+		case c.Block:
+			solution[i] = puzBlock
+		case c.Solution == "":
+			return inputerror.New("puzzle without a complete solution cannot be scrambled")
+		default:
+			solution[i] = strings.ToUpper(c.Solution)[0]
+		}
+	}
+	letters := p.solutionLetters(solution)
+	if len(letters) < 12 || !lettersOnly(letters) {
+		return inputerror.New("only solutions of at least 12 letters A-Z can be scrambled")
+	}
+	x := p.extras()
+	x.scrambledChecksum = checksum(letters, 0)
+	p.setSolutionLetters(solution, scramble(letters, digits))
+	x.scrambledSolution = solution
+	for _, c := range p.Cells {
+		c.Solution = ""
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package puzzle_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPuzzle returns a 5x5 puzzle with blocks in the corners:
+//
+//	#CAT#
+//	HORSE
+//	ORBIT
+//	TESTS
+//	#SKY#
+func testPuzzle() *puzzle.Puzzle {
+	rows := []string{"#CAT#", "HORSE", "ORBIT", "TESTS", "#SKY#"}
+	p := puzzle.New(5, 5)
+	for r, row := range rows {
+		for c, ch := range row {
+			cell := p.Cell(r, c)
+			if ch == '#' {
+				cell.Block = true
+				continue
+			}
+			cell.Solution = string(ch)
+		}
+	}
+	p.Title = "Test puzzle"
+	p.Author = "Crossies"
+	p.Copyright = "© nobody"
+	p.Notes = "Some notes"
+	for _, s := range p.Slots() {
+		p.Clues = append(p.Clues, &puzzle.Clue{Number: s.Number, Direction: s.Direction, Text: "Clue for " + s.Label()})
+	}
+	return p
+}
+
+func roundTrip(t *testing.T, p *puzzle.Puzzle) *puzzle.Puzzle {
+	var b bytes.Buffer
+	require.NoError(t, p.WritePuz(&b))
+	p2, err := puzzle.ReadPuz(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	var b2 bytes.Buffer
+	require.NoError(t, p2.WritePuz(&b2))
+	assert.Equal(t, b.Bytes(), b2.Bytes())
+	return p2
+}
+
+func TestSlots(t *testing.T) {
+	p := testPuzzle()
+	var labels []string
+	for _, s := range p.Slots() {
+		labels = append(labels, s.Label())
+	}
+	assert.Equal(t, []string{"1A", "1D", "2D", "3D", "4A", "4D", "5D", "6A", "7A", "8A"}, labels)
+	assert.Equal(t, "cat", p.Answer(p.Slot("1A")))
+	assert.Equal(t, "cores", p.Answer(p.Slot("1D")))
+}
+
+func TestRoundTrip(t *testing.T) {
+	p := testPuzzle()
+	p.Cell(1, 0).Fill = "H"
+	p.Cell(1, 1).Fill = "OR"
+	p.Cell(2, 2).Solution = "RB"
+	p.Cell(3, 3).Circled = true
+
+	p2 := roundTrip(t, p)
+	assert.Equal(t, p.Title, p2.Title)
+	assert.Equal(t, p.Copyright, p2.Copyright)
+	assert.Equal(t, p.Notes, p2.Notes)
+	assert.Equal(t, p.Clues, p2.Clues)
+	assert.Equal(t, "hor...", p2.Frame(p2.Slot("4A")))
+	assert.Equal(t, "RB", p2.Cell(2, 2).Solution)
+	assert.True(t, p2.Cell(3, 3).Circled)
+	assert.False(t, p2.Cell(3, 2).Circled)
+}
+
+func TestChecksum(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, testPuzzle().WritePuz(&b))
+	data := b.Bytes()
+	data[len(data)-20] ^= 0x01
+	_, err := puzzle.ReadPuz(bytes.NewReader(data))
+	require.Error(t, err)
+	assert.True(t, inputerror.IsInputError(err))
+}
+
+func TestWriteWithoutSolution(t *testing.T) {
+	p := testPuzzle()
+	p.Cell(1, 2).Solution = ""
+	err := p.WritePuz(&bytes.Buffer{})
+	require.Error(t, err)
+	assert.True(t, inputerror.IsInputError(err))
+	assert.Contains(t, err.Error(), "row 2, column 3")
+}
+
+func TestScramble(t *testing.T) {
+	p := testPuzzle()
+	require.NoError(t, p.Scramble(1234))
+	assert.True(t, p.Scrambled())
+	assert.Equal(t, ".....", p.Answer(p.Slot("4A")))
+
+	p2 := roundTrip(t, p)
+	require.True(t, p2.Scrambled())
+	require.Error(t, p2.Unscramble(4321))
+	key, err := p2.FindKey()
+	require.NoError(t, err)
+	assert.Equal(t, 1234, key)
+	assert.Equal(t, "horse", p2.Answer(p2.Slot("4A")))
+}
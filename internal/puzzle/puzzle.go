@@ -0,0 +1,233 @@
+package puzzle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSize is the maximum width and height of a grid that is read, the limit of the .puz format.
+const maxSize = 255
+
+// Direction is the direction of a clue.
+type Direction string
+
+// available directions
+const (
+	Across Direction = "across"
+	Down   Direction = "down"
+)
+
+// Short returns the single letter abbreviation of the direction.
+func (d Direction) Short() string {
+	if d == Down {
+		return "D"
+	}
+	return "A"
+}
+
+// Cell is a single square of the grid.
+type Cell struct {
+	Block    bool   `json:"block,omitempty"`
+	Solution string `json:"solution,omitempty"` // solution letters, more than one for a rebus square
+	Fill     string `json:"fill,omitempty"`     // current fill, empty if not filled in
+	Circled  bool   `json:"circled,omitempty"`
+	Shaded   bool   `json:"shaded,omitempty"`
+}
+
+// Clue is a clue for a slot in the grid.
+type Clue struct {
+	Number      int       `json:"number"`
+	Direction   Direction `json:"direction"`
+	Text        string    `json:"text"`
+	Enumeration string    `json:"enumeration,omitempty"` // enumeration when not part of the text, for example 3,4
+}
+
+// Label returns the clue number and direction, for example 12A.
+func (c *Clue) Label() string {
+	return fmt.Sprintf("%d%s", c.Number, c.Direction.Short())
+}
+
+// FullText returns the clue text followed by the enumeration in parentheses, if there is one.
+func (c *Clue) FullText() string {
+	if c.Enumeration == "" {
+		return c.Text
+	}
+	return fmt.Sprintf("%s (%s)", c.Text, c.Enumeration)
+}
+
+// sortClues orders clues by number, across before down.
+func sortClues(clues []*Clue) {
+	sort.SliceStable(clues, func(i, j int) bool {
+		if clues[i].Number != clues[j].Number {
+			return clues[i].Number < clues[j].Number
+		}
+		return clues[i].Direction == Across && clues[j].Direction == Down
+	})
+}
+
+// Puzzle is a crossword grid along with its clues.
+type Puzzle struct {
+	Title     string  `json:"title,omitempty"`
+	Author    string  `json:"author,omitempty"`
+	Copyright string  `json:"copyright,omitempty"`
+	Notes     string  `json:"notes,omitempty"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Cells     []*Cell `json:"cells"` // cells in row-major order
+	Clues     []*Clue `json:"clues"` // clues ordered by number, across before down
+	puz       *puzExtras
+}
+
+// New returns an empty puzzle of the supplied size with all white squares.
+func New(width, height int) *Puzzle {
+	p := &Puzzle{Width: width, Height: height}
+	for i := 0; i < width*height; i++ {
+		p.Cells = append(p.Cells, &Cell{})
+	}
+	return p
+}
+
+// Cell returns the cell at the supplied position, nil if out of bounds.
+func (p *Puzzle) Cell(row, col int) *Cell {
+	if row < 0 || col < 0 || row >= p.Height || col >= p.Width {
+		return nil
+	}
+	return p.Cells[row*p.Width+col]
+}
+
+func (p *Puzzle) white(row, col int) bool {
+	c := p.Cell(row, col)
+	return c != nil && !c.Block
+}
+
+// Slot is a run of white squares that has a clue.
+type Slot struct {
+	Number    int       `json:"number"`
+	Direction Direction `json:"direction"`
+	Row       int       `json:"row"`
+	Col       int       `json:"col"`
+	Length    int       `json:"length"`
+	Clue      *Clue     `json:"clue,omitempty"`
+}
+
+// Label returns the slot number and direction, for example 12A.
+func (s *Slot) Label() string {
+	return fmt.Sprintf("%d%s", s.Number, s.Direction.Short())
+}
+
+// Positions returns the row and column of every square in the slot.
+func (s *Slot) Positions() [][2]int {
+	var ret [][2]int
+	for i := 0; i < s.Length; i++ {
+		if s.Direction == Across {
+			ret = append(ret, [2]int{s.Row, s.Col + i})
+		} else {
+			ret = append(ret, [2]int{s.Row + i, s.Col})
+		}
+	}
+	return ret
+}
+
+// Numbers returns the clue number of every square in row-major order, 0 for unnumbered squares.
+func (p *Puzzle) Numbers() []int {
+	ret := make([]int, len(p.Cells))
+	n := 0
+	for r := 0; r < p.Height; r++ {
+		for c := 0; c < p.Width; c++ {
+			if !p.white(r, c) {
+				continue
+			}
+			across := !p.white(r, c-1) && p.white(r, c+1)
+			down := !p.white(r-1, c) && p.white(r+1, c)
+			if across || down {
+				n++
+				ret[r*p.Width+c] = n
+			}
+		}
+	}
+	return ret
+}
+
+// Slots returns the slots of the grid ordered by number, across before down, linked to their clues.
+func (p *Puzzle) Slots() []*Slot {
+	numbers := p.Numbers()
+	clues := map[string]*Clue{}
+	for _, c := range p.Clues {
+		clues[c.Label()] = c
+	}
+	var ret []*Slot
+	for r := 0; r < p.Height; r++ {
+		for c := 0; c < p.Width; c++ {
+			num := numbers[r*p.Width+c]
+			if num == 0 {
+				continue
+			}
+			if !p.white(r, c-1) && p.white(r, c+1) {
+				s := &Slot{Number: num, Direction: Across, Row: r, Col: c}
+				for p.white(r, c+s.Length) {
+					s.Length++
+				}
+				s.Clue = clues[s.Label()]
+				ret = append(ret, s)
+			}
+			if !p.white(r-1, c) && p.white(r+1, c) {
+				s := &Slot{Number: num, Direction: Down, Row: r, Col: c}
+				for p.white(r+s.Length, c) {
+					s.Length++
+				}
+				s.Clue = clues[s.Label()]
+				ret = append(ret, s)
+			}
+		}
+	}
+	return ret
+}
+
+// Slot returns the slot with the supplied label, such as 12A or 3D, nil if there is none.
+func (p *Puzzle) Slot(label string) *Slot {
+	label = strings.ToUpper(strings.TrimSpace(label))
+	for _, s := range p.Slots() {
+		if s.Label() == label {
+			return s
+		}
+	}
+	return nil
+}
+
+// Frame returns the current fill of a slot with a '.' for every empty square. Rebus squares contribute
+// all their letters.
+func (p *Puzzle) Frame(s *Slot) string {
+	var b strings.Builder
+	for _, pos := range s.Positions() {
+		fill := p.Cell(pos[0], pos[1]).Fill
+		if fill == "" {
+			fill = "."
+		}
+		b.WriteString(strings.ToLower(fill))
+	}
+	return b.String()
+}
+
+// Answer returns the solution of a slot, with a '.' for squares whose solution is unknown.
+func (p *Puzzle) Answer(s *Slot) string {
+	var b strings.Builder
+	for _, pos := range s.Positions() {
+		sol := p.Cell(pos[0], pos[1]).Solution
+		if sol == "" {
+			sol = "."
+		}
+		b.WriteString(strings.ToLower(sol))
+	}
+	return b.String()
+}
+
+// HasSolution returns true if every white square has a solution.
+func (p *Puzzle) HasSolution() bool {
+	for _, c := range p.Cells {
+		if !c.Block && c.Solution == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package render
+
+import "strings"
+
+// widths of the printable ASCII characters in the standard Helvetica fonts, in thousandths of the font size
+var (
+	helvetica = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+	}
+	helveticaBold = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// winAnsi maps the punctuation commonly found in clues to its code in the PDF WinAnsiEncoding.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// widths of the characters in winAnsi, regular then bold
+var winAnsiWidths = map[byte][2]int{
+	0x80: {556, 556}, 0x85: {1000, 1000}, 0x91: {222, 278}, 0x92: {222, 278}, 0x93: {333, 500},
+	0x94: {333, 500}, 0x95: {350, 350}, 0x96: {556, 556}, 0x97: {1000, 1000},
+}
+
+// encode converts text to WinAnsiEncoding, replacing characters it cannot represent with a question mark.
+func encode(s string) []byte {
+	var ret []byte
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			ret = append(ret, ' ')
+		case r >= 32 && r < 127, r >= 160 && r < 256:
+			ret = append(ret, byte(r))
+		case winAnsi[r] != 0:
+			ret = append(ret, winAnsi[r])
+		default:
+			ret = append(ret, '?')
+		}
+	}
+	return ret
+}
+
+func charWidth(b byte, bold bool) int {
+	if b >= 32 && b < 127 {
+		if bold {
+			return helveticaBold[b-32]
+		}
+		return helvetica[b-32]
+	}
+	if w, ok := winAnsiWidths[b]; ok {
+		if bold {
+			return w[1]
+		}
+		return w[0]
+	}
+	return 556 // accented letters are close to the width of a typical lower case letter
+}
+
+// textWidth returns the width of text in points at the supplied font size.
+func textWidth(s string, size float64, bold bool) float64 {
+	total := 0
+	for _, b := range encode(s) {
+		total += charWidth(b, bold)
+	}
+	return float64(total) * size / 1000
+}
+
+// wrap breaks text into lines no wider than the supplied width. Words wider than a line get a line of their own.
+func wrap(s string, width float64, size float64, bold bool) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && textWidth(candidate, size, bold) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,125 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/puzzle"
+)
+
+// bezier control point distance for a quarter circle of unit radius
+const kappa = 0.5523
+
+// fixed PDF objects, pages follow with a page object and its content stream each
+const (
+	pdfCatalog = iota + 1
+	pdfPages
+	pdfFont
+	pdfBoldFont
+	pdfInfo
+	pdfFirstPage
+)
+
+// pdfString returns text as a PDF string literal in WinAnsiEncoding.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range encode(s) {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfContent returns the content stream of a page, flipping positions to PDF's bottom up coordinates.
+func pdfContent(pg *page, height float64) string {
+	var b strings.Builder
+	for _, o := range pg.ops {
+		switch o.kind {
+		case opRect:
+			rect := fmt.Sprintf("%s %s %s %s re", num(o.x), num(height-o.y-o.h), num(o.w), num(o.h))
+			if o.fill >= 0 {
+				fmt.Fprintf(&b, "%s g %s f\n", num(o.fill), rect)
+			}
+			if o.stroke > 0 {
+				fmt.Fprintf(&b, "0 G %s w %s S\n", num(o.stroke), rect)
+			}
+		case opCircle:
+			x, y, r, k := o.x, height-o.y, o.w, o.w*kappa
+			fmt.Fprintf(&b, "0 G %s w %s %s m\n", num(o.stroke), num(x+r), num(y))
+			fmt.Fprintf(&b, "%s %s %s %s %s %s c\n", num(x+r), num(y+k), num(x+k), num(y+r), num(x), num(y+r))
+			fmt.Fprintf(&b, "%s %s %s %s %s %s c\n", num(x-k), num(y+r), num(x-r), num(y+k), num(x-r), num(y))
+			fmt.Fprintf(&b, "%s %s %s %s %s %s c\n", num(x-r), num(y-k), num(x-k), num(y-r), num(x), num(y-r))
+			fmt.Fprintf(&b, "%s %s %s %s %s %s c S\n", num(x+k), num(y-r), num(x+r), num(y-k), num(x+r), num(y))
+		case opText:
+			x := o.x
+			switch o.align {
+			case alignCenter:
+				x -= textWidth(o.text, o.size, o.bold) / 2
+			case alignRight:
+				x -= textWidth(o.text, o.size, o.bold)
+			}
+			font := "F1"
+			if o.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&b, "0 g BT /%s %s Tf %s %s Td %s Tj ET\n", font, num(o.size), num(x), num(height-o.y),
+				pdfString(o.text))
+		}
+	}
+	return b.String()
+}
+
+// WritePDF writes the puzzle as a PDF, with the clues continuing on further pages when they do not fit.
+func WritePDF(out io.Writer, p *puzzle.Puzzle, opts Options) error {
+	width, height, err := opts.Paper.size()
+	if err != nil {
+		return err
+	}
+	doc := layout(p, opts, width, height)
+
+	var b bytes.Buffer
+	count := pdfFirstPage + 2*len(doc.pages)
+	offsets := make([]int, count)
+	object := func(n int, body string) {
+		offsets[n] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", n, body)
+	}
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object(pdfCatalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPages))
+	var kids []string
+	for i := range doc.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", pdfFirstPage+2*i))
+	}
+	object(pdfPages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(doc.pages), num(width), num(height)))
+	object(pdfFont, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object(pdfBoldFont, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(pdfInfo, fmt.Sprintf("<< /Title %s /Author %s /Producer (crossie) >>", pdfString(p.Title), pdfString(p.Author)))
+	for i, pg := range doc.pages {
+		n := pdfFirstPage + 2*i
+		object(n, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> "+
+			"/Contents %d 0 R >>", pdfPages, pdfFont, pdfBoldFont, n+1))
+		content := pdfContent(pg, height)
+		object(n+1, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", count)
+	for _, off := range offsets[1:] {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		count, pdfCatalog, pdfInfo, xref)
+	_, err = out.Write(b.Bytes())
+	return err
+}
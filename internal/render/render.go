@@ -0,0 +1,326 @@
+// Package render draws puzzles for printing, as SVG or as PDF. Both formats are written directly, with text set
+// in the standard Helvetica fonts, so rendering needs no external libraries or font files.
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+)
+
+// Paper is a page size.
+type Paper string
+
+// supported page sizes
+const (
+	PaperA4     Paper = "a4"
+	PaperLetter Paper = "letter"
+)
+
+// size returns the width and height of the paper in points.
+func (p Paper) size() (float64, float64, error) {
+	switch p {
+	case "", PaperA4:
+		return 595.28, 841.89, nil
+	case PaperLetter:
+		return 612, 792, nil
+	}
+	return 0, 0, inputerror.New(fmt.Sprintf("unknown paper size %q, expected a4 or letter", p))
+}
+
+// Options control how a puzzle is drawn.
+type Options struct {
+	Solution   bool  // show the solution letters in the grid
+	LargePrint bool  // use bigger squares, text and lines
+	GridOnly   bool  // leave out the title and clues
+	Paper      Paper // page size, A4 when empty. SVG output uses only the width.
+}
+
+// style is the size of everything on the page, in points.
+type style struct {
+	margin  float64
+	cell    float64 // largest square size
+	number  float64
+	letter  float64
+	clue    float64
+	heading float64
+	title   float64
+	thin    float64 // width of the lines between squares
+	thick   float64 // width of the border of the grid
+	gap     float64 // space between the grid and clues, and between clue columns
+	columns int
+}
+
+var (
+	normalStyle = style{margin: 40, cell: 26, number: 7, letter: 15, clue: 10, heading: 12, title: 16,
+		thin: 0.75, thick: 2, gap: 18, columns: 2}
+	largeStyle = style{margin: 36, cell: 40, number: 11, letter: 24, clue: 16, heading: 19, title: 24,
+		thin: 1.25, thick: 3, gap: 24, columns: 1}
+)
+
+// gray level of shaded squares, light enough for letters to stand out
+const shade = 0.75
+
+type opKind int
+
+const (
+	opRect opKind = iota
+	opCircle
+	opText
+)
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+	alignRight
+)
+
+// op is something drawn on a page. Positions are in points from the top left corner of the page.
+type op struct {
+	kind   opKind
+	x, y   float64 // top left of a rectangle, centre of a circle or the baseline position of text
+	w, h   float64 // size of a rectangle, w is the radius of a circle
+	fill   float64 // gray level of the fill from 0 for black to 1 for white, negative for none
+	stroke float64 // width of the black outline, 0 for none
+	text   string
+	size   float64
+	bold   bool
+	align  align
+}
+
+type page struct {
+	ops []op
+}
+
+func (pg *page) rect(x, y, w, h, fill, stroke float64) {
+	pg.ops = append(pg.ops, op{kind: opRect, x: x, y: y, w: w, h: h, fill: fill, stroke: stroke})
+}
+
+func (pg *page) circle(x, y, r, stroke float64) {
+	pg.ops = append(pg.ops, op{kind: opCircle, x: x, y: y, w: r, fill: -1, stroke: stroke})
+}
+
+func (pg *page) text(x, y, size float64, bold bool, a align, s string) {
+	pg.ops = append(pg.ops, op{kind: opText, x: x, y: y, size: size, bold: bold, align: a, text: s})
+}
+
+type document struct {
+	width, height float64
+	pages         []*page
+}
+
+// item is a heading or clue in the clue list.
+type item struct {
+	heading bool
+	label   string
+	lines   []string
+	height  float64
+}
+
+type layouter struct {
+	st     style
+	opts   Options
+	doc    *document
+	page   *page
+	height float64 // page height, 0 for a single page as tall as its content
+	y      float64
+}
+
+// layout arranges a puzzle on pages of the supplied size. A zero height gives a single page as tall as needed.
+// A puzzle without a grid, such as a clue list, has just its title and clues.
+func layout(p *puzzle.Puzzle, opts Options, width, height float64) *document {
+	l := &layouter{st: normalStyle, opts: opts, doc: &document{width: width}, height: height}
+	if opts.LargePrint {
+		l.st = largeStyle
+	}
+	l.newPage()
+	if !opts.GridOnly {
+		l.title(p)
+	}
+	if p.Width > 0 && p.Height > 0 {
+		l.grid(p)
+	}
+	if !opts.GridOnly {
+		l.clues(p)
+	}
+	l.doc.height = height
+	if height == 0 {
+		l.doc.height = math.Ceil(l.y - l.st.gap + l.st.margin)
+	}
+	return l.doc
+}
+
+func (l *layouter) newPage() {
+	l.page = &page{}
+	l.doc.pages = append(l.doc.pages, l.page)
+	l.y = l.st.margin
+}
+
+func (l *layouter) title(p *puzzle.Puzzle) {
+	st := l.st
+	center := l.doc.width / 2
+	if p.Title != "" {
+		l.page.text(center, l.y+st.title, st.title, true, alignCenter, p.Title)
+		l.y += st.title * 1.5
+	}
+	if p.Author != "" {
+		l.page.text(center, l.y+st.clue, st.clue, false, alignCenter, p.Author)
+		l.y += st.clue * 1.5
+	}
+	if p.Title != "" || p.Author != "" {
+		l.y += st.clue
+	}
+}
+
+func (l *layouter) grid(p *puzzle.Puzzle) {
+	st := l.st
+	w, h := float64(p.Width), float64(p.Height)
+	cell := math.Min(st.cell, (l.doc.width-2*st.margin)/w)
+	if l.height > 0 {
+		cell = math.Min(cell, (l.height-st.margin-l.y)/h)
+	}
+	scale := cell / st.cell
+	number := math.Max(st.number*scale, 4)
+	x0, y0 := (l.doc.width-cell*w)/2, l.y
+	numbers := p.Numbers()
+	for r := 0; r < p.Height; r++ {
+		for c := 0; c < p.Width; c++ {
+			i := r*p.Width + c
+			x, y := x0+float64(c)*cell, y0+float64(r)*cell
+			sq := p.Cells[i]
+			if sq.Block {
+				l.page.rect(x, y, cell, cell, 0, 0)
+				continue
+			}
+			if sq.Shaded {
+				l.page.rect(x, y, cell, cell, shade, 0)
+			}
+			if sq.Circled {
+				l.page.circle(x+cell/2, y+cell/2, cell/2-st.thin*1.5, st.thin)
+			}
+			if numbers[i] > 0 {
+				l.page.text(x+cell*0.07, y+number, number, false, alignLeft, strconv.Itoa(numbers[i]))
+			}
+			if l.opts.Solution && sq.Solution != "" {
+				size := st.letter * scale
+				if tw := textWidth(sq.Solution, size, false); tw > cell*0.8 {
+					size *= cell * 0.8 / tw // rebus squares
+				}
+				// centre the capitals in the space below the number
+				baseline := y + number + (cell-number+0.718*size)/2
+				l.page.text(x+cell/2, baseline, size, false, alignCenter, sq.Solution)
+			}
+			l.page.rect(x, y, cell, cell, -1, st.thin)
+		}
+	}
+	l.page.rect(x0, y0, cell*w, cell*h, -1, st.thick)
+	l.y = y0 + cell*h + st.gap
+}
+
+// clueItems returns the headings and clues wrapped to the supplied width, and the width of the clue numbers.
+func (l *layouter) clueItems(p *puzzle.Puzzle, width float64) ([]*item, float64) {
+	st := l.st
+	labelWidth := 0.0
+	for _, c := range p.Clues {
+		labelWidth = math.Max(labelWidth, textWidth(strconv.Itoa(c.Number), st.clue, true))
+	}
+	labelWidth += st.clue * 0.6
+	var ret []*item
+	// clue lists may have clues without a direction, which follow the others without a heading
+	for _, dir := range []puzzle.Direction{puzzle.Across, puzzle.Down, ""} {
+		var heading *item
+		switch dir {
+		case puzzle.Across:
+			heading = &item{heading: true, lines: []string{"Across"}, height: st.heading * 1.8}
+		case puzzle.Down:
+			heading = &item{heading: true, lines: []string{"Down"}, height: st.heading * 1.8}
+		}
+		var clues []*item
+		for _, c := range p.Clues {
+			if c.Direction != dir {
+				continue
+			}
+			lines := wrap(c.FullText(), width-labelWidth, st.clue, false)
+			if len(lines) == 0 {
+				lines = []string{""}
+			}
+			clues = append(clues, &item{
+				label:  strconv.Itoa(c.Number),
+				lines:  lines,
+				height: float64(len(lines))*st.clue*1.25 + st.clue*0.35,
+			})
+		}
+		if len(clues) > 0 && heading != nil {
+			ret = append(ret, heading)
+		}
+		ret = append(ret, clues...)
+	}
+	return ret, labelWidth
+}
+
+// clues flows the clues into columns, starting new pages as needed. Headings are kept with their first clue.
+func (l *layouter) clues(p *puzzle.Puzzle) {
+	st := l.st
+	content := l.doc.width - 2*st.margin
+	colWidth := (content - st.gap*float64(st.columns-1)) / float64(st.columns)
+	items, labelWidth := l.clueItems(p, colWidth)
+	if len(items) == 0 {
+		return
+	}
+
+	top := l.y
+	bottom := l.height - st.margin
+	if l.height == 0 {
+		// balance the columns: each is filled to at least the average height before moving on, so they all fit
+		total, tallest := 0.0, 0.0
+		for _, it := range items {
+			total += it.height
+			tallest = math.Max(tallest, it.height)
+		}
+		bottom = top + total/float64(st.columns) + tallest
+	}
+	col, y, end := 0, top, top
+	for i, it := range items {
+		need := it.height
+		if it.heading && i+1 < len(items) {
+			need += items[i+1].height
+		}
+		if y+need > bottom && y > top {
+			col++
+			switch {
+			case col < st.columns:
+			case l.height == 0:
+				col = st.columns - 1 // cannot happen with balanced columns, but keep going in the last one
+			default:
+				l.newPage()
+				col, top, bottom = 0, l.y, l.height-st.margin
+				end = top
+			}
+			if col > 0 || l.height > 0 {
+				y = top
+			}
+		}
+		x := st.margin + float64(col)*(colWidth+st.gap)
+		if it.heading {
+			l.page.text(x, y+st.heading*1.2, st.heading, true, alignLeft, it.lines[0])
+		} else {
+			l.page.text(x+labelWidth-st.clue*0.6, y+st.clue, st.clue, true, alignRight, it.label)
+			for n, line := range it.lines {
+				l.page.text(x+labelWidth, y+st.clue+float64(n)*st.clue*1.25, st.clue, false, alignLeft, line)
+			}
+		}
+		y += it.height
+		end = math.Max(end, y)
+	}
+	l.y = end + st.gap
+}
+
+// num formats a number of points for output.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,101 @@
+package render_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/cluelist"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPuzzle(t *testing.T, clues int) *puzzle.Puzzle {
+	p, err := puzzle.ParseGrid(strings.NewReader("CAT#\nO#AX\nWHY#\n"))
+	require.NoError(t, err)
+	p.Title = "Team <Night>"
+	p.Cell(0, 0).Circled = true
+	p.Cell(2, 1).Shaded = true
+	for i := 0; i < clues; i++ {
+		p.Clues = append(p.Clues, &puzzle.Clue{Number: i + 1, Direction: puzzle.Across,
+			Text: "Feline (or so it’s said) that walks alone through the night in search of something to eat"})
+	}
+	return p
+}
+
+func TestSVG(t *testing.T) {
+	p := testPuzzle(t, 3)
+	var b bytes.Buffer
+	require.NoError(t, render.WriteSVG(&b, p, render.Options{}))
+	svg := b.String()
+	assert.True(t, strings.HasPrefix(svg, "<?xml"))
+	assert.Contains(t, svg, "<title>Team &lt;Night&gt;</title>")
+	assert.Contains(t, svg, "<circle ")
+	assert.Contains(t, svg, `fill="#bfbfbf"`)
+	assert.Contains(t, svg, ">Across</text>")
+	assert.NotContains(t, svg, ">WHY</text>")
+	assert.NotContains(t, svg, ">C</text>")
+
+	b.Reset()
+	require.NoError(t, render.WriteSVG(&b, p, render.Options{Solution: true, GridOnly: true}))
+	svg = b.String()
+	assert.Contains(t, svg, ">C</text>")
+	assert.NotContains(t, svg, "Across")
+}
+
+func pageCount(t *testing.T, pdf string) int {
+	m := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(pdf)
+	require.NotNil(t, m)
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+func TestPDF(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, render.WritePDF(&b, testPuzzle(t, 3), render.Options{Solution: true}))
+	pdf := b.String()
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4"))
+	assert.Equal(t, 1, pageCount(t, pdf))
+	assert.Contains(t, pdf, `(Feline \(or so it\222s said\)`)
+	assert.Contains(t, pdf, "(C) Tj")
+
+	// the cross reference table must point at the objects
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	require.NotNil(t, m)
+	xref, _ := strconv.Atoi(m[1])
+	require.True(t, strings.HasPrefix(pdf[xref:], "xref\n"))
+	for n, line := range strings.Split(pdf[xref:], "\n")[3:8] {
+		off, _ := strconv.Atoi(line[:10])
+		assert.True(t, strings.HasPrefix(pdf[off:], fmt.Sprintf("%d 0 obj", n+1)))
+	}
+
+	b.Reset()
+	require.NoError(t, render.WritePDF(&b, testPuzzle(t, 60), render.Options{LargePrint: true, Paper: render.PaperLetter}))
+	assert.Greater(t, pageCount(t, b.String()), 2)
+	assert.Contains(t, b.String(), "/MediaBox [0 0 612 792]")
+
+	err := render.WritePDF(&b, testPuzzle(t, 0), render.Options{Paper: "a3"})
+	assert.True(t, inputerror.IsInputError(err))
+}
+
+func TestClueList(t *testing.T) {
+	clues, _ := cluelist.Parse("4 No direction (3,4)\nAcross\n1 Tear apart cat (4)\nDown\n2 Mad teens fly around (9)\n")
+	var b bytes.Buffer
+	require.NoError(t, render.WriteSVG(&b, cluelist.Puzzle(clues), render.Options{}))
+	svg := b.String()
+	assert.Contains(t, svg, ">Across</text>")
+	assert.Contains(t, svg, ">Down</text>")
+	assert.Contains(t, svg, ">Tear apart cat (4)</text>")
+	assert.Contains(t, svg, ">No direction (3,4)</text>")
+	assert.NotContains(t, svg, "stroke-width", "there is no grid")
+
+	b.Reset()
+	require.NoError(t, render.WritePDF(&b, cluelist.Puzzle(clues), render.Options{}))
+	assert.Contains(t, b.String(), "(Mad teens fly around \\(9\\)) Tj")
+}
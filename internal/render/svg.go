@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/gotwarlost/crossies/internal/puzzle"
+)
+
+func svgColor(gray float64) string {
+	v := int(math.Round(gray * 255))
+	return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+}
+
+func svgEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// WriteSVG writes the puzzle as a single SVG image as wide as the paper and as tall as its content.
+func WriteSVG(out io.Writer, p *puzzle.Puzzle, opts Options) error {
+	width, _, err := opts.Paper.size()
+	if err != nil {
+		return err
+	}
+	doc := layout(p, opts, width, 0)
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]s" height="%[2]s" viewBox="0 0 %[1]s %[2]s" `+
+		`font-family="Helvetica, Arial, sans-serif">`+"\n", num(doc.width), num(doc.height))
+	if p.Title != "" {
+		fmt.Fprintf(&b, "<title>%s</title>\n", svgEscape(p.Title))
+	}
+	b.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` + "\n")
+	for _, o := range doc.pages[0].ops {
+		switch o.kind {
+		case opRect, opCircle:
+			fill, stroke := "none", ""
+			if o.fill >= 0 {
+				fill = svgColor(o.fill)
+			}
+			if o.stroke > 0 {
+				stroke = fmt.Sprintf(` stroke="#000000" stroke-width="%s"`, num(o.stroke))
+			}
+			if o.kind == opRect {
+				fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"%s/>`+"\n",
+					num(o.x), num(o.y), num(o.w), num(o.h), fill, stroke)
+			} else {
+				fmt.Fprintf(&b, `<circle cx="%s" cy="%s" r="%s" fill="%s"%s/>`+"\n", num(o.x), num(o.y), num(o.w), fill, stroke)
+			}
+		case opText:
+			attrs := ""
+			if o.bold {
+				attrs += ` font-weight="bold"`
+			}
+			switch o.align {
+			case alignCenter:
+				attrs += ` text-anchor="middle"`
+			case alignRight:
+				attrs += ` text-anchor="end"`
+			}
+			fmt.Fprintf(&b, `<text x="%s" y="%s" font-size="%s"%s>%s</text>`+"\n",
+				num(o.x), num(o.y), num(o.size), attrs, svgEscape(o.text))
+		}
+	}
+	b.WriteString("</svg>\n")
+	_, err = out.Write(b.Bytes())
+	return err
+}
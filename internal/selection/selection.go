@@ -0,0 +1,199 @@
+package selection
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+)
+
+// Mode is the letters that are selected.
+type Mode string
+
+// available modes
+const (
+	ModeFirst  Mode = "first"  // "initially", "at first"
+	ModeLast   Mode = "last"   // "at last", "finally"
+	ModeOdd    Mode = "odd"    // "oddly", letters 1, 3, 5...
+	ModeEven   Mode = "even"   // "evenly", "regularly", letters 2, 4, 6...
+	ModeMiddle Mode = "middle" // "heart of", the middle letter or two
+)
+
+// Scope is what the selection is applied to.
+type Scope string
+
+// available scopes
+const (
+	ScopeWords Scope = "words" // selection from each word of the run, concatenated
+	ScopeRun   Scope = "run"   // selection from all the letters of the run
+)
+
+var defaultScopes = map[Mode]Scope{
+	ModeFirst:  ScopeWords,
+	ModeLast:   ScopeWords,
+	ModeOdd:    ScopeRun,
+	ModeEven:   ScopeRun,
+	ModeMiddle: ScopeWords,
+}
+
+// Query is a query to find answers made of letters selected from clue words.
+type Query struct {
+	Clue        string `json:"clue"`                  // clue text, optionally ending with an enumeration like (5)
+	Mode        Mode   `json:"mode"`                  // the letters to select
+	Scope       Scope  `json:"scope,omitempty"`       // whether to select from each word or the whole run
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the answer when not part of the clue
+	Frame       string `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	words       []clue.Word
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	if q.Mode == "" {
+		q.Mode = ModeFirst
+	}
+	scope, ok := defaultScopes[q.Mode]
+	if !ok {
+		return inputerror.New(fmt.Sprintf("invalid selection mode %q", q.Mode))
+	}
+	if q.Scope == "" {
+		q.Scope = scope
+	}
+	if q.Scope != ScopeWords && q.Scope != ScopeRun {
+		return inputerror.New(fmt.Sprintf("invalid selection scope %q", q.Scope))
+	}
+	text, e, ok := clue.SplitEnumeration(q.Clue)
+	enum := q.Enumeration
+	if enum == "" && ok {
+		enum = e.String()
+	}
+	q.words = clue.Split(text)
+	if len(q.words) == 0 {
+		return inputerror.New("empty clue not allowed")
+	}
+	var err error
+	q.pattern, err = clue.NewPattern(enum, q.Frame)
+	if err != nil {
+		return err
+	}
+	if q.pattern.Length() == 0 {
+		return inputerror.New("no enumeration or frame specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Clue = values.Get("clue")
+	q.Mode = Mode(values.Get("mode"))
+	q.Scope = Scope(values.Get("scope"))
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Entry is an answer formed by selecting letters from a run of clue words.
+type Entry struct {
+	Answer string `json:"answer"`
+	Span   string `json:"span"`  // the clue words that the letters were selected from
+	Start  int    `json:"start"` // index of the first word of the span
+	End    int    `json:"end"`   // index one past the last word of the span
+}
+
+// Result is the result of a query
+type Result struct {
+	Query   *Query   `json:"query,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Select returns the letters selected from the supplied letters by the mode.
+func (m Mode) Select(letters string) string {
+	n := len(letters)
+	if n == 0 {
+		return ""
+	}
+	switch m {
+	case ModeFirst:
+		return letters[:1]
+	case ModeLast:
+		return letters[n-1:]
+	case ModeMiddle:
+		if n%2 == 0 && n > 2 {
+			return letters[n/2-1 : n/2+1]
+		}
+		return letters[n/2 : n/2+1]
+	}
+	start := 0
+	if m == ModeEven {
+		start = 1
+	}
+	var ret []byte
+	for i := start; i < n; i += 2 {
+		ret = append(ret, letters[i])
+	}
+	return string(ret)
+}
+
+func (q *Query) selectRun(words []clue.Word) string {
+	if q.Scope == ScopeRun {
+		letters := ""
+		for _, w := range words {
+			letters += w.Letters
+		}
+		return q.Mode.Select(letters)
+	}
+	ret := ""
+	for _, w := range words {
+		ret += q.Mode.Select(w.Letters)
+	}
+	return ret
+}
+
+// Run applies the selection to every contiguous run of clue words and returns the results that fit
+// the answer and are real words according to the default word source.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	source := wordlist.Default()
+	var cands []*Entry
+	var words []string
+	for start := range q.words {
+		for end := start + 1; end <= len(q.words); end++ {
+			answer := q.selectRun(q.words[start:end])
+			// a longer run can select fewer letters, e.g. the middle of the whole run
+			if !q.pattern.Match(answer) {
+				continue
+			}
+			cands = append(cands, &Entry{
+				Answer: answer,
+				Span:   clue.Join(q.words[start:end]),
+				Start:  start,
+				End:    end,
+			})
+			words = append(words, answer)
+		}
+	}
+	if len(cands) == 0 {
+		return nil, errcode.NoResults("no %s letter selections of length %d found", q.Mode, q.pattern.Length())
+	}
+	found, err := source.Words(words)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, c := range cands {
+		if found[c.Answer] {
+			entries = append(entries, c)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errcode.NoResults("no %s letter selections form a word", q.Mode)
+	}
+	return &Result{Query: q, Entries: entries}, nil
+}
@@ -0,0 +1,101 @@
+package selection_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/selection"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testWords = wordlist.New([]string{"pals", "sea", "pal", "hot", "on", "c"})
+
+func TestMain(m *testing.M) {
+	wordlist.SetDefault(testWords)
+	os.Exit(m.Run())
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		mode            selection.Mode
+		letters, result string
+	}{
+		{selection.ModeFirst, "pearls", "p"},
+		{selection.ModeLast, "pearls", "s"},
+		{selection.ModeOdd, "pearls", "pal"},
+		{selection.ModeEven, "pearls", "ers"},
+		{selection.ModeMiddle, "pearls", "ar"},
+		{selection.ModeMiddle, "pearl", "a"},
+		{selection.ModeMiddle, "of", "f"},
+		{selection.ModeOdd, "", ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.result, test.mode.Select(test.letters), "%s %s", test.mode, test.letters)
+	}
+}
+
+func TestSelection(t *testing.T) {
+	tests := []struct {
+		name  string
+		query selection.Query
+		entry selection.Entry
+	}{
+		{
+			name:  "first letters of words",
+			query: selection.Query{Clue: "Initially pretty awful lazy sorts (4)"},
+			entry: selection.Entry{Answer: "pals", Span: "pretty awful lazy sorts", Start: 1, End: 5},
+		},
+		{
+			name:  "last letters of words",
+			query: selection.Query{Clue: "Bus the area finally", Mode: selection.ModeLast, Enumeration: "3"},
+			entry: selection.Entry{Answer: "sea", Span: "Bus the area", Start: 0, End: 3},
+		},
+		{
+			name:  "odd letters of run",
+			query: selection.Query{Clue: "Oddly pearls (3)", Mode: selection.ModeOdd},
+			entry: selection.Entry{Answer: "pal", Span: "pearls", Start: 1, End: 2},
+		},
+		{
+			name:  "even letters of run",
+			query: selection.Query{Clue: "Evenly, the oat (3)", Mode: selection.ModeEven, Frame: "h.."},
+			entry: selection.Entry{Answer: "hot", Span: "the oat", Start: 1, End: 3},
+		},
+		{
+			name:  "middle letters of words",
+			query: selection.Query{Clue: "Centres of dog ant (2)", Mode: selection.ModeMiddle},
+			entry: selection.Entry{Answer: "on", Span: "dog ant", Start: 2, End: 4},
+		},
+		{
+			name:  "middle of run after a longer selection",
+			query: selection.Query{Clue: "ab cd e (1)", Mode: selection.ModeMiddle, Scope: selection.ScopeRun},
+			entry: selection.Entry{Answer: "c", Span: "ab cd e", Start: 0, End: 3},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := test.query.Run()
+			require.NoError(t, err)
+			require.Len(t, res.Entries, 1)
+			assert.Equal(t, test.entry, *res.Entries[0])
+		})
+	}
+}
+
+func TestSelectionErrors(t *testing.T) {
+	for _, q := range []selection.Query{{Clue: "Oddly pearls (9)"}, {Clue: "Oddly pearls (2)"}} {
+		_, err := q.Run()
+		assert.Equal(t, errcode.NotFound, errcode.Of(err), q.Clue)
+	}
+	for _, q := range []selection.Query{
+		{Clue: "Oddly pearls (3)", Mode: "sideways"},
+		{Clue: "Oddly pearls (3)", Scope: "clue"},
+		{Clue: "(3)"},
+		{Clue: "Oddly pearls"},
+	} {
+		_, err := q.Run()
+		assert.Equal(t, errcode.InvalidInput, errcode.Of(err), "%+v", q)
+	}
+}
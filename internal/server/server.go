@@ -15,8 +15,8 @@ func DefaultRoot() string {
 	return "site"
 }
 
-func baseHandler() (http.Handler, error) {
-	apiHandler, err := api.New()
+func baseHandler(opts api.Options) (http.Handler, error) {
+	apiHandler, err := api.New(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -24,8 +24,8 @@ func baseHandler() (http.Handler, error) {
 }
 
 // CGIHandler returns a handler that serves the API
-func CGIHandler() (http.Handler, error) {
-	apiHandler, err := baseHandler()
+func CGIHandler(opts api.Options) (http.Handler, error) {
+	apiHandler, err := baseHandler(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +35,7 @@ func CGIHandler() (http.Handler, error) {
 }
 
 // Handler returns a handler that serves the API as well as static files
-func Handler(root string) (http.Handler, error) {
+func Handler(root string, opts api.Options) (http.Handler, error) {
 	if root == "" {
 		root = DefaultRoot()
 	}
@@ -44,7 +44,7 @@ func Handler(root string) (http.Handler, error) {
 		return nil, errors.Wrapf(err, "find root filesystem %q", root)
 	}
 
-	apiHandler, err := baseHandler()
+	apiHandler, err := baseHandler(opts)
 	if err != nil {
 		return nil, err
 	}
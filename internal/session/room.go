@@ -0,0 +1,182 @@
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+)
+
+const maxEvents = 500
+
+// operation types
+const (
+	OpCell   = "cell"   // set or clear the value of a square
+	OpCursor = "cursor" // move a solver's cursor, which is not saved
+	OpLeave  = "leave"  // remove a solver's cursor, which is not saved
+)
+
+// Op is a change made by a solver in a shared session.
+type Op struct {
+	Type      string           `json:"type"`
+	Client    string           `json:"client,omitempty"` // name of the solver making the change
+	Row       int              `json:"row"`
+	Col       int              `json:"col"`
+	Value     string           `json:"value,omitempty"`
+	Pencil    bool             `json:"pencil,omitempty"`    // value is a tentative pencil mark
+	Base      int64            `json:"base,omitempty"`      // sequence of the square's last change seen by the solver
+	Direction puzzle.Direction `json:"direction,omitempty"` // cursor direction
+}
+
+// Event is an operation applied to a session. Events are numbered in the order they were applied, which is
+// the order every solver must apply them in.
+type Event struct {
+	Op
+	Seq      int64     `json:"seq"`
+	Time     time.Time `json:"time"`
+	Conflict bool      `json:"conflict,omitempty"` // the change replaced a value the solver had not seen
+}
+
+// CellState is the collaborative state of a square.
+type CellState struct {
+	Seq    int64  `json:"seq"` // sequence of the last change
+	Pencil bool   `json:"pencil,omitempty"`
+	By     string `json:"by,omitempty"`
+}
+
+// Cursor is the position of a solver in the grid. Cursors move too often to be saved with the session.
+type Cursor struct {
+	Row       int              `json:"row"`
+	Col       int              `json:"col"`
+	Direction puzzle.Direction `json:"direction,omitempty"`
+	Seen      time.Time        `json:"seen"`
+}
+
+// Room is the shared solving state of a session.
+type Room struct {
+	Seq    int64              `json:"seq"`
+	Cells  map[int]*CellState `json:"cells,omitempty"`  // keyed by row-major index
+	Events []*Event           `json:"events,omitempty"` // recent events for solvers catching up
+}
+
+// CellSnapshot is the full state of a square that has been changed.
+type CellSnapshot struct {
+	Row   int    `json:"row"`
+	Col   int    `json:"col"`
+	Value string `json:"value,omitempty"`
+	CellState
+}
+
+// Snapshot is the complete shared state of a session, sent to solvers too far behind to catch up from events.
+type Snapshot struct {
+	Seq   int64           `json:"seq"`
+	Grid  [][]string      `json:"grid"`
+	Cells []*CellSnapshot `json:"cells,omitempty"`
+}
+
+// Sync brings a solver up to date from a sequence number, with either the events after it or a snapshot.
+type Sync struct {
+	Seq      int64              `json:"seq"`
+	Events   []*Event           `json:"events,omitempty"`
+	Snapshot *Snapshot          `json:"snapshot,omitempty"`
+	Cursors  map[string]*Cursor `json:"cursors,omitempty"` // current cursors of other solvers
+}
+
+func (s *Session) room() *Room {
+	if s.Room == nil {
+		s.Room = &Room{}
+	}
+	if s.Room.Cells == nil {
+		s.Room.Cells = map[int]*CellState{}
+	}
+	return s.Room
+}
+
+func (s *Session) record(op Op, conflict bool) *Event {
+	r := s.room()
+	r.Seq++
+	e := &Event{Op: op, Seq: r.Seq, Time: time.Now(), Conflict: conflict}
+	r.Events = append(r.Events, e)
+	if len(r.Events) > maxEvents {
+		r.Events = append([]*Event(nil), r.Events[len(r.Events)-maxEvents:]...)
+	}
+	return e
+}
+
+// Apply applies a solver's change to the grid and returns the resulting event. Concurrent edits of the same
+// square are resolved by the order in which they are applied, the last writer winning.
+func (s *Session) Apply(op Op) (*Event, error) {
+	if s.Puzzle == nil {
+		return nil, inputerror.New("shared solving needs a puzzle with a grid")
+	}
+	if op.Type != OpCell {
+		return nil, inputerror.New(fmt.Sprintf("unknown operation %q", op.Type))
+	}
+	return s.setCell(op)
+}
+
+// Cursor returns the cursor for a cursor operation, checking that it is on a white square.
+func (s *Session) Cursor(op Op) (*Cursor, error) {
+	if s.Puzzle == nil {
+		return nil, inputerror.New("shared solving needs a puzzle with a grid")
+	}
+	cell := s.Puzzle.Cell(op.Row, op.Col)
+	if cell == nil || cell.Block {
+		return nil, inputerror.New(fmt.Sprintf("no white square at row %d, column %d", op.Row, op.Col))
+	}
+	return &Cursor{Row: op.Row, Col: op.Col, Direction: op.Direction, Seen: time.Now()}, nil
+}
+
+func (s *Session) setCell(op Op) (*Event, error) {
+	if err := s.assignCell(op.Row, op.Col, op.Value); err != nil {
+		return nil, err
+	}
+	cell := s.Puzzle.Cell(op.Row, op.Col)
+	op.Value = cell.Fill
+	op.Pencil = op.Pencil && cell.Fill != ""
+	r := s.room()
+	idx := op.Row*s.Puzzle.Width + op.Col
+	prev := r.Cells[idx]
+	conflict := prev != nil && op.Base < prev.Seq && prev.By != op.Client
+	e := s.record(op, conflict)
+	r.Cells[idx] = &CellState{Seq: e.Seq, Pencil: op.Pencil, By: op.Client}
+	return e, nil
+}
+
+// Snapshot returns the complete shared state of the session.
+func (s *Session) Snapshot() *Snapshot {
+	room := s.room()
+	snap := &Snapshot{Seq: room.Seq, Grid: s.View().Grid}
+	for r, row := range snap.Grid {
+		for c := range row {
+			if st := room.Cells[r*s.Puzzle.Width+c]; st != nil {
+				snap.Cells = append(snap.Cells, &CellSnapshot{Row: r, Col: c, Value: row[c], CellState: *st})
+			}
+		}
+	}
+	return snap
+}
+
+// SyncSince returns what a solver who has seen events up to the supplied sequence needs to catch up.
+// A negative sequence always returns a snapshot.
+func (s *Session) SyncSince(seq int64) (*Sync, error) {
+	if s.Puzzle == nil {
+		return nil, inputerror.New("shared solving needs a puzzle with a grid")
+	}
+	r := s.room()
+	ret := &Sync{Seq: r.Seq}
+	if seq >= r.Seq {
+		return ret, nil
+	}
+	if seq < 0 || len(r.Events) == 0 || r.Events[0].Seq > seq+1 {
+		ret.Snapshot = s.Snapshot()
+		return ret, nil
+	}
+	for _, e := range r.Events {
+		if e.Seq > seq {
+			ret.Events = append(ret.Events, e)
+		}
+	}
+	return ret, nil
+}
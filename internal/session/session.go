@@ -0,0 +1,261 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/anagrams"
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/cluelist"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/findwords"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+)
+
+// Session is a puzzle or clue list being solved, along with its current fill.
+type Session struct {
+	ID      string            `json:"id"`
+	Created time.Time         `json:"created"`
+	Updated time.Time         `json:"updated"`
+	Expires time.Time         `json:"expires"`
+	Puzzle  *puzzle.Puzzle    `json:"puzzle,omitempty"`  // grid and clues for a puzzle upload
+	Clues   []*cluelist.Clue  `json:"clues,omitempty"`   // clues for a clue list upload, which has no grid
+	Answers map[string]string `json:"answers,omitempty"` // fill of clue list sessions keyed by label
+	Room    *Room             `json:"room,omitempty"`    // shared solving state of puzzle sessions
+}
+
+// NewFromPuzzle returns a session for the supplied puzzle.
+func NewFromPuzzle(p *puzzle.Puzzle) *Session {
+	return &Session{Puzzle: p}
+}
+
+// NewFromClues returns a session for a pasted clue list.
+func NewFromClues(text string) (*Session, error) {
+	clues, _ := cluelist.Parse(text)
+	if len(clues) == 0 {
+		return nil, inputerror.New("no numbered clues found in text")
+	}
+	return &Session{Clues: clues, Answers: map[string]string{}}, nil
+}
+
+// Slot is a single answer in a session with its current frame.
+type Slot struct {
+	Label       string           `json:"label"`
+	Number      int              `json:"number"`
+	Direction   puzzle.Direction `json:"direction,omitempty"`
+	Cells       [][2]int         `json:"cells,omitempty"` // row and column of each square, for puzzles
+	Length      int              `json:"length,omitempty"`
+	Clue        string           `json:"clue,omitempty"`
+	Enumeration string           `json:"enumeration,omitempty"` // for example 3,4
+	Frame       string           `json:"frame,omitempty"`       // current fill, '.' for empty squares
+}
+
+// Slots returns the slots of the session in clue order.
+func (s *Session) Slots() []*Slot {
+	var ret []*Slot
+	if s.Puzzle == nil {
+		for _, c := range s.Clues {
+			slot := &Slot{Label: c.Label, Number: c.Number, Direction: c.Direction, Length: c.Length, Clue: c.Text}
+			if c.Enumeration != nil {
+				slot.Enumeration = strings.Trim(c.Enumeration.String(), "()")
+			}
+			slot.Frame = s.Answers[c.Label]
+			if slot.Frame == "" {
+				slot.Frame = c.Frame
+			}
+			ret = append(ret, slot)
+		}
+		return ret
+	}
+	for _, ps := range s.Puzzle.Slots() {
+		slot := &Slot{
+			Label:     ps.Label(),
+			Number:    ps.Number,
+			Direction: ps.Direction,
+			Cells:     ps.Positions(),
+			Length:    ps.Length,
+			Frame:     s.Puzzle.Frame(ps),
+		}
+		if ps.Clue != nil {
+			slot.Clue, slot.Enumeration = ps.Clue.Text, ps.Clue.Enumeration
+			if text, e, ok := clue.SplitEnumeration(ps.Clue.Text); ok && slot.Enumeration == "" {
+				slot.Clue, slot.Enumeration = text, strings.Trim(e.String(), "()")
+			}
+		}
+		ret = append(ret, slot)
+	}
+	return ret
+}
+
+// Slot returns the slot with the supplied label, such as 12A.
+func (s *Session) Slot(label string) (*Slot, error) {
+	for _, slot := range s.Slots() {
+		if strings.EqualFold(slot.Label, label) {
+			return slot, nil
+		}
+	}
+	return nil, inputerror.New(fmt.Sprintf("no slot %q in session", label))
+}
+
+// Pattern returns the pattern that answers for the slot must fit, given the current fill.
+func (s *Session) Pattern(label string) (*clue.Pattern, error) {
+	slot, err := s.Slot(label)
+	if err != nil {
+		return nil, err
+	}
+	enum := slot.Enumeration
+	if e, err := clue.ParseEnumeration(enum); err != nil || (slot.Length > 0 && e.Length() != slot.Length) {
+		enum = "" // enumerations that do not fit the grid are ignored
+	}
+	return clue.NewPattern(enum, slot.Frame)
+}
+
+// Fill sets the letters of a slot, with '.' clearing a square.
+func (s *Session) Fill(label string, fill string) error {
+	slot, err := s.Slot(label)
+	if err != nil {
+		return err
+	}
+	pat, err := clue.NewPattern("", fill)
+	if err != nil {
+		return err
+	}
+	fill = pat.Frame()
+	if slot.Length > 0 && len(fill) != slot.Length {
+		return inputerror.New(fmt.Sprintf("fill %q does not fit %s, which has %d letters", fill, slot.Label, slot.Length))
+	}
+	if s.Puzzle == nil {
+		s.Answers[slot.Label] = fill
+		return nil
+	}
+	for i, pos := range slot.Cells {
+		if err := s.SetCell(pos[0], pos[1], fill[i:i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetCell sets the fill of a single grid square, with an empty value or '.' clearing it. The change is
+// recorded as an event for solvers sharing the session.
+func (s *Session) SetCell(row, col int, value string) error {
+	if s.Puzzle == nil {
+		return inputerror.New("session has no grid")
+	}
+	_, err := s.setCell(Op{Type: OpCell, Row: row, Col: col, Value: value, Base: s.room().Seq})
+	return err
+}
+
+func (s *Session) assignCell(row, col int, value string) error {
+	cell := s.Puzzle.Cell(row, col)
+	if cell == nil || cell.Block {
+		return inputerror.New(fmt.Sprintf("no white square at row %d, column %d", row, col))
+	}
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "." {
+		value = ""
+	}
+	if clue.Letters(value) != strings.ToLower(value) {
+		return inputerror.New(fmt.Sprintf("invalid fill %q", value))
+	}
+	cell.Fill = value
+	return nil
+}
+
+// View is what a solver sees of a session, which excludes any solution.
+type View struct {
+	ID      string     `json:"id"`
+	Expires time.Time  `json:"expires"`
+	Title   string     `json:"title,omitempty"`
+	Author  string     `json:"author,omitempty"`
+	Width   int        `json:"width,omitempty"`
+	Height  int        `json:"height,omitempty"`
+	Grid    [][]string `json:"grid,omitempty"` // "#" for blocks, "" for empty squares, fill otherwise
+	Slots   []*Slot    `json:"slots"`
+}
+
+// View returns the solver view of the session.
+func (s *Session) View() *View {
+	v := &View{ID: s.ID, Expires: s.Expires, Slots: s.Slots()}
+	if p := s.Puzzle; p != nil {
+		v.Title, v.Author, v.Width, v.Height = p.Title, p.Author, p.Width, p.Height
+		for r := 0; r < p.Height; r++ {
+			var row []string
+			for c := 0; c < p.Width; c++ {
+				cell := p.Cell(r, c)
+				if cell.Block {
+					row = append(row, "#")
+				} else {
+					row = append(row, cell.Fill)
+				}
+			}
+			v.Grid = append(v.Grid, row)
+		}
+	}
+	return v
+}
+
+// FindWords finds words that fit the current frame of a slot.
+func (s *Session) FindWords(label string, page int) (*findwords.Result, error) {
+	pat, err := s.Pattern(label)
+	if err != nil {
+		return nil, err
+	}
+	if pat.Length() == 0 {
+		return nil, inputerror.New(fmt.Sprintf("length of %s is not known", label))
+	}
+	q := findwords.Query{Frame: pat.Frame(), Page: page}
+	if q.Frame == "" {
+		q.Frame = strings.Repeat(".", pat.Length())
+	}
+	return q.Run()
+}
+
+// Anagrams returns the anagrams of the phrase that fit the current frame of a slot.
+func (s *Session) Anagrams(label string, phrase string) (*anagrams.Result, error) {
+	pat, err := s.Pattern(label)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(clue.Letters(phrase)); pat.Length() > 0 && n != pat.Length() {
+		return nil, inputerror.New(fmt.Sprintf("%q has %d letters but %s needs %d", phrase, n, label, pat.Length()))
+	}
+	res, err := anagrams.Solve(anagrams.Query{Phrase: phrase})
+	if err != nil {
+		return nil, err
+	}
+	ret := &anagrams.Result{Phrases: []string{}}
+	for _, p := range res.Phrases {
+		if pat.Match(p) {
+			ret.Phrases = append(ret.Phrases, p)
+		}
+	}
+	return ret, nil
+}
+
+// Synonyms returns the synonyms of the word that fit the current frame of a slot.
+func (s *Session) Synonyms(label string, word string, all bool) (*synonyms.Result, error) {
+	pat, err := s.Pattern(label)
+	if err != nil {
+		return nil, err
+	}
+	q := synonyms.Query{Word: word, All: all, Sort: synonyms.SortDisplay}
+	res, err := q.Run()
+	if err != nil {
+		return nil, err
+	}
+	var entries []*synonyms.Entry
+	for _, e := range res.Entries {
+		if pat.Match(e.Synonym) {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errcode.NoResults("no synonyms for %q fit %s", word, label)
+	}
+	res.Entries = entries
+	return res, nil
+}
@@ -0,0 +1,140 @@
+package session_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/puzzle"
+	"github.com/gotwarlost/crossies/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPuzzle() *puzzle.Puzzle {
+	p := puzzle.New(3, 3)
+	for i, ch := range "CATAGOBEE" {
+		p.Cells[i].Solution = string(ch)
+	}
+	for _, s := range p.Slots() {
+		p.Clues = append(p.Clues, &puzzle.Clue{Number: s.Number, Direction: s.Direction, Text: "Clue " + s.Label() + " (3)"})
+	}
+	return p
+}
+
+func TestStoreAndFill(t *testing.T) {
+	store, err := session.NewStore(t.TempDir(), time.Hour, time.Hour)
+	require.NoError(t, err)
+	sess, err := store.Create(session.NewFromPuzzle(testPuzzle()))
+	require.NoError(t, err)
+	assert.Len(t, sess.ID, 32)
+
+	_, err = store.Update(sess.ID, func(s *session.Session) error {
+		return s.Fill("1a", "c.t")
+	})
+	require.NoError(t, err)
+	_, err = store.Update(sess.ID, func(s *session.Session) error {
+		return s.SetCell(1, 0, "a")
+	})
+	require.NoError(t, err)
+
+	sess, err = store.Get(sess.ID)
+	require.NoError(t, err)
+	pat, err := sess.Pattern("1D")
+	require.NoError(t, err)
+	assert.Equal(t, "ca.", pat.Frame())
+
+	view := sess.View()
+	assert.Equal(t, []string{"C", "", "T"}, view.Grid[0])
+	assert.Equal(t, "Clue 1A", view.Slots[0].Clue)
+	assert.Equal(t, "3", view.Slots[0].Enumeration)
+
+	_, err = store.Update(sess.ID, func(s *session.Session) error {
+		return s.Fill("1A", "cats")
+	})
+	assert.True(t, inputerror.IsInputError(err))
+
+	require.NoError(t, store.Delete(sess.ID))
+	_, err = store.Get(sess.ID)
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+	_, err = store.Get("../../etc/passwd")
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+}
+
+func TestClueListSession(t *testing.T) {
+	sess, err := session.NewFromClues("Across\n1 Feline (3)\n4 Wading bird (5)")
+	require.NoError(t, err)
+	require.NoError(t, sess.Fill("4A", "e...t"))
+	pat, err := sess.Pattern("4A")
+	require.NoError(t, err)
+	assert.True(t, pat.Match("egret"))
+	assert.False(t, pat.Match("heron"))
+}
+
+func TestExpiry(t *testing.T) {
+	store, err := session.NewStore(t.TempDir(), 50*time.Millisecond, time.Nanosecond)
+	require.NoError(t, err)
+	sess, err := store.Create(session.NewFromPuzzle(testPuzzle()))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = store.Get(sess.ID)
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+	n, err := store.Cleanup()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestDeleteDuringUpdate(t *testing.T) {
+	store, err := session.NewStore(t.TempDir(), time.Hour, time.Hour)
+	require.NoError(t, err)
+	sess, err := store.Create(session.NewFromPuzzle(testPuzzle()))
+	require.NoError(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	updated := make(chan error)
+	go func() {
+		_, err := store.Update(sess.ID, func(s *session.Session) error {
+			close(started)
+			<-release
+			return s.Fill("1A", "cat")
+		})
+		updated <- err
+	}()
+	<-started
+	deleted := make(chan error)
+	go func() {
+		deleted <- store.Delete(sess.ID)
+	}()
+	select {
+	case <-deleted:
+		t.Fatal("delete did not wait for the update")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-updated)
+	require.NoError(t, <-deleted)
+	_, err = store.Get(sess.ID)
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+}
+
+func TestCursors(t *testing.T) {
+	store, err := session.NewStore(t.TempDir(), time.Hour, time.Hour)
+	require.NoError(t, err)
+	sess, err := store.Create(session.NewFromPuzzle(testPuzzle()))
+	require.NoError(t, err)
+
+	require.NoError(t, store.MoveCursors(sess.ID, map[string]*session.Cursor{
+		"alice": {Row: 1, Col: 2, Seen: time.Now()},
+		"bob":   {Row: 0, Col: 0, Seen: time.Now().Add(-3 * time.Minute)},
+	}))
+	cursors, err := store.Cursors(sess.ID)
+	require.NoError(t, err)
+	require.Len(t, cursors, 1, "cursors that have not moved recently are forgotten")
+	assert.Equal(t, 2, cursors["alice"].Col)
+
+	require.NoError(t, store.MoveCursors(sess.ID, map[string]*session.Cursor{"alice": nil}))
+	cursors, err = store.Cursors(sess.ID)
+	require.NoError(t, err)
+	assert.Empty(t, cursors)
+}
@@ -0,0 +1,340 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	fileSuffix   = ".json"
+	cursorSuffix = ".cursors"
+	lockSuffix   = ".lock"
+	cleanupFile  = ".last-cleanup"
+	lockTimeout  = 5 * time.Second
+	staleLockAge = 30 * time.Second
+	cursorMaxAge = 2 * time.Minute // time after which a cursor that has not moved is forgotten
+)
+
+var idRE = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Config is the configuration of the session store.
+type Config struct {
+	Dir             string        // directory in which sessions are stored
+	TTL             time.Duration // time after the last change at which a session expires
+	CleanupInterval time.Duration // minimum time between removals of expired sessions, 0 to never remove them
+}
+
+// AddFlags adds flags for the session store to the supplied command.
+func (c *Config) AddFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&c.Dir, "session-dir", filepath.Join(os.TempDir(), "crossie-sessions"), "directory in which solving sessions are stored")
+	f.DurationVar(&c.TTL, "session-ttl", 7*24*time.Hour, "time after the last change at which a session expires")
+	f.DurationVar(&c.CleanupInterval, "session-cleanup", time.Hour, "minimum time between removals of expired sessions, 0 to disable")
+}
+
+// Open returns the store for the configuration.
+func (c *Config) Open() (*Store, error) {
+	return NewStore(c.Dir, c.TTL, c.CleanupInterval)
+}
+
+// Store keeps sessions as JSON files in a directory. Writes are atomic and updates are serialized with lock
+// files, so that a store may be shared by several processes, as is common with FastCGI. Expired sessions
+// are removed while handling requests rather than by a background task. The cursors of the solvers of a
+// session are kept in a separate file, as they move too often to be saved with it.
+type Store struct {
+	dir     string
+	ttl     time.Duration
+	cleanup time.Duration
+	now     func() time.Time
+}
+
+// NewStore returns a store using the supplied directory, creating it if needed.
+func NewStore(dir string, ttl, cleanup time.Duration) (*Store, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("session TTL must be positive, got %v", ttl)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "create session directory")
+	}
+	return &Store{dir: dir, ttl: ttl, cleanup: cleanup, now: time.Now}, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+fileSuffix)
+}
+
+func (s *Store) cursorPath(id string) string {
+	return filepath.Join(s.dir, id+cursorSuffix)
+}
+
+func notFound(id string) error {
+	return errcode.Errorf(errcode.NotFound, "session %q not found or expired", id)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate session id")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Create saves a new session, assigning its ID and expiry.
+func (s *Store) Create(sess *Session) (*Session, error) {
+	s.maybeCleanup()
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	sess.ID = id
+	sess.Created = s.now()
+	if err := s.write(sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Get returns the session with the supplied ID.
+func (s *Store) Get(id string) (*Session, error) {
+	if !idRE.MatchString(id) {
+		return nil, notFound(id)
+	}
+	b, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, notFound(id)
+		}
+		return nil, errors.Wrap(err, "read session")
+	}
+	var sess Session
+	if err := json.Unmarshal(b, &sess); err != nil {
+		return nil, errors.Wrapf(err, "decode session %s", id)
+	}
+	if s.now().After(sess.Expires) {
+		return nil, notFound(id)
+	}
+	return &sess, nil
+}
+
+// Update applies the supplied function to a session and saves the result, extending its expiry.
+// The session is not saved when the function returns an error.
+func (s *Store) Update(id string, fn func(sess *Session) error) (*Session, error) {
+	s.maybeCleanup()
+	if !idRE.MatchString(id) {
+		return nil, notFound(id)
+	}
+	unlock, err := s.lock(id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	sess, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(sess); err != nil {
+		return nil, err
+	}
+	if err := s.write(sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Delete removes a session, waiting for any update in progress so that the update cannot save it again.
+func (s *Store) Delete(id string) error {
+	if !idRE.MatchString(id) {
+		return notFound(id)
+	}
+	unlock, err := s.lock(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_ = os.Remove(s.cursorPath(id))
+	err = os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return notFound(id)
+	}
+	return err
+}
+
+// Cursors returns the cursors of the solvers of a session that have moved in the last couple of minutes.
+func (s *Store) Cursors(id string) (map[string]*Cursor, error) {
+	if !idRE.MatchString(id) {
+		return nil, notFound(id)
+	}
+	b, err := os.ReadFile(s.cursorPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read cursors")
+	}
+	var cursors map[string]*Cursor
+	if err := json.Unmarshal(b, &cursors); err != nil {
+		return nil, errors.Wrapf(err, "decode cursors of session %s", id)
+	}
+	now := s.now()
+	var ret map[string]*Cursor
+	for client, c := range cursors {
+		if now.Sub(c.Seen) >= cursorMaxAge {
+			continue
+		}
+		if ret == nil {
+			ret = map[string]*Cursor{}
+		}
+		ret[client] = c
+	}
+	return ret, nil
+}
+
+// MoveCursors sets the cursors of solvers of a session, removing those that are nil.
+func (s *Store) MoveCursors(id string, cursors map[string]*Cursor) error {
+	if !idRE.MatchString(id) {
+		return notFound(id)
+	}
+	unlock, err := s.lock(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, err := s.Cursors(id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		current = map[string]*Cursor{}
+	}
+	for client, c := range cursors {
+		if c == nil {
+			delete(current, client)
+		} else {
+			current[client] = c
+		}
+	}
+	if len(current) == 0 {
+		if err := os.Remove(s.cursorPath(id)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "save cursors")
+		}
+		return nil
+	}
+	return errors.Wrap(s.writeJSON(s.cursorPath(id), current), "save cursors")
+}
+
+// write saves the session, extending its expiry.
+func (s *Store) write(sess *Session) error {
+	sess.Updated = s.now()
+	sess.Expires = sess.Updated.Add(s.ttl)
+	return errors.Wrap(s.writeJSON(s.path(sess.ID), sess), "save session")
+}
+
+// writeJSON saves a value to a temporary file and renames it into place, so readers never see partial data.
+func (s *Store) writeJSON(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "encode")
+	}
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lock creates a lock file for the session, waiting for any other holder, and returns a function to release it.
+func (s *Store) lock(id string) (func(), error) {
+	name := filepath.Join(s.dir, id+lockSuffix)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrap(err, "lock session")
+		}
+		// locks left behind by a crashed process are broken after a while
+		if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errcode.Errorf(errcode.Timeout, "session %s is busy, try again", id)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// maybeCleanup removes expired sessions if the cleanup interval has passed since the last time any process did so.
+func (s *Store) maybeCleanup() {
+	if s.cleanup <= 0 {
+		return
+	}
+	marker := filepath.Join(s.dir, cleanupFile)
+	now := s.now()
+	if info, err := os.Stat(marker); err == nil && now.Sub(info.ModTime()) < s.cleanup {
+		return
+	}
+	if err := os.WriteFile(marker, nil, 0600); err != nil {
+		return
+	}
+	_, _ = s.Cleanup()
+}
+
+// Cleanup removes expired sessions and returns the number removed.
+func (s *Store) Cleanup() (int, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, errors.Wrap(err, "list sessions")
+	}
+	now := s.now()
+	removed := 0
+	for _, e := range files {
+		name := e.Name()
+		f, err := e.Info()
+		if err != nil {
+			continue // removed by another process
+		}
+		switch {
+		case strings.HasSuffix(name, fileSuffix):
+			// files are written on every change, so the modification time gives the expiry without reading them
+			if now.Sub(f.ModTime()) > s.ttl {
+				if os.Remove(filepath.Join(s.dir, name)) == nil {
+					removed++
+				}
+			}
+		case strings.HasSuffix(name, cursorSuffix):
+			// every cursor in a file that has not changed for this long has been forgotten
+			if now.Sub(f.ModTime()) > cursorMaxAge {
+				_ = os.Remove(filepath.Join(s.dir, name))
+			}
+		case strings.HasSuffix(name, ".tmp"):
+			if now.Sub(f.ModTime()) > staleLockAge {
+				_ = os.Remove(filepath.Join(s.dir, name))
+			}
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,231 @@
+package spoonerism
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+)
+
+const defaultLimit = 100
+
+// Query is a query for spoonerisms. When a phrase is supplied, the initial sounds of its words are swapped.
+// Otherwise phrases that fit the enumeration and frame and whose spoonerism is also a phrase are found,
+// which requires a local word list.
+type Query struct {
+	Phrase      string `json:"phrase,omitempty"`      // phrase to spoonerize
+	Enumeration string `json:"enumeration,omitempty"` // enumeration of the phrase to find, e.g. 4,5
+	Frame       string `json:"frame,omitempty"`       // known letters of the phrase to find with '.' for unknowns
+	Limit       int    `json:"limit,omitempty"`       // maximum phrases to find
+	words       []clue.Word
+	enumeration clue.Enumeration
+	pattern     *clue.Pattern
+}
+
+func (q *Query) initialize() error {
+	q.words = clue.Split(q.Phrase)
+	if q.Limit <= 0 {
+		q.Limit = defaultLimit
+	}
+	if len(q.words) > 0 {
+		if len(q.words) < 2 {
+			return inputerror.New("phrase must have at least two words")
+		}
+		return nil
+	}
+	if q.Enumeration == "" {
+		return inputerror.New("no phrase or enumeration specified")
+	}
+	var err error
+	q.enumeration, err = clue.ParseEnumeration(q.Enumeration)
+	if err != nil {
+		return err
+	}
+	if len(q.enumeration.Parts) != 2 {
+		return inputerror.New("enumeration must have exactly two words")
+	}
+	q.pattern, err = clue.NewPattern(q.Enumeration, q.Frame)
+	return err
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Phrase = values.Get("phrase")
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if s := values.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return q, inputerror.New(fmt.Sprintf("invalid limit %q", s))
+		}
+		q.Limit = n
+	}
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Entry is a phrase and its spoonerism.
+type Entry struct {
+	Phrase     string `json:"phrase"`
+	Spoonerism string `json:"spoonerism"`
+}
+
+// Result is the result of a query
+type Result struct {
+	Query   *Query   `json:"query,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Split splits a word into its onset, the consonants before the first vowel, and the rest. A leading
+// "qu" is part of the onset and "y" is a vowel unless it starts the word.
+func Split(word string) (onset, rime string) {
+	for i := 0; i < len(word); i++ {
+		switch word[i] {
+		case 'a', 'e', 'i', 'o', 'u':
+			if word[i] == 'u' && i > 0 && word[i-1] == 'q' {
+				continue
+			}
+			return word[:i], word[i:]
+		case 'y':
+			if i > 0 {
+				return word[:i], word[i:]
+			}
+		}
+	}
+	return word, ""
+}
+
+// Swap swaps the onsets of two words, returning false if the result would be the same.
+func Swap(first, second string) (string, string, bool) {
+	o1, r1 := Split(first)
+	o2, r2 := Split(second)
+	if o1 == o2 || r1 == "" || r2 == "" {
+		return "", "", false
+	}
+	return o2 + r1, o1 + r2, true
+}
+
+func (q *Query) spoonerize(source wordlist.Source) ([]*Entry, error) {
+	type swap struct {
+		i, j   int
+		first  string
+		second string
+	}
+	var swaps []*swap
+	var cands []string
+	for i := range q.words {
+		for j := i + 1; j < len(q.words); j++ {
+			a, b, ok := Swap(q.words[i].Letters, q.words[j].Letters)
+			if !ok {
+				continue
+			}
+			swaps = append(swaps, &swap{i: i, j: j, first: a, second: b})
+			cands = append(cands, a, b)
+		}
+	}
+	found, err := source.Words(cands)
+	if err != nil {
+		return nil, err
+	}
+	var ret []*Entry
+	for _, s := range swaps {
+		if !found[s.first] || !found[s.second] {
+			continue
+		}
+		var parts []string
+		for k, w := range q.words {
+			switch k {
+			case s.i:
+				parts = append(parts, s.first)
+			case s.j:
+				parts = append(parts, s.second)
+			default:
+				parts = append(parts, w.Letters)
+			}
+		}
+		ret = append(ret, &Entry{Phrase: q.Phrase, Spoonerism: strings.Join(parts, " ")})
+	}
+	return ret, nil
+}
+
+func (q *Query) search(list *wordlist.List) []*Entry {
+	a, b := q.enumeration.Parts[0], q.enumeration.Parts[1]
+	// onsets that can precede each rime, and second words indexed by onset
+	onsets := map[string][]string{}
+	seconds := map[string][]string{}
+	for n := 1; n <= a+b; n++ {
+		for _, w := range list.WithLength(n) {
+			o, r := Split(w)
+			if r == "" {
+				continue
+			}
+			onsets[r] = append(onsets[r], o)
+			if n == b {
+				seconds[o] = append(seconds[o], r)
+			}
+		}
+	}
+	var ret []*Entry
+	for _, first := range list.WithLength(a) {
+		o1, r1 := Split(first)
+		if r1 == "" {
+			continue
+		}
+		for _, o2 := range onsets[r1] {
+			if o2 == o1 {
+				continue
+			}
+			for _, r2 := range seconds[o2] {
+				if !list.Contains(o1+r2) || !q.pattern.Match(first+o2+r2) {
+					continue
+				}
+				ret = append(ret, &Entry{
+					Phrase:     first + " " + o2 + r2,
+					Spoonerism: o2 + r1 + " " + o1 + r2,
+				})
+				if len(ret) == q.Limit {
+					return ret
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// Run spoonerizes the query phrase, or searches for phrases with real spoonerisms, using the default
+// word source.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	source := wordlist.Default()
+	var entries []*Entry
+	if len(q.words) > 0 {
+		var err error
+		entries, err = q.spoonerize(source)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		list, ok := source.(*wordlist.List)
+		if !ok {
+			return nil, errcode.New(errcode.NotConfigured, "finding phrases by enumeration requires a local word list")
+		}
+		entries = q.search(list)
+	}
+	if len(entries) == 0 {
+		return nil, errcode.NoResults("no spoonerisms found")
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Phrase < entries[j].Phrase
+	})
+	return &Result{Query: q, Entries: entries}, nil
+}
@@ -0,0 +1,67 @@
+package spoonerism_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/spoonerism"
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var words = wordlist.New([]string{"crushing", "blow", "blushing", "crow", "flushing", "crown", "bad", "salad"})
+
+func TestMain(m *testing.M) {
+	wordlist.SetDefault(words)
+	os.Exit(m.Run())
+}
+
+func TestSplit(t *testing.T) {
+	for word, parts := range map[string][2]string{
+		"crude": {"cr", "ude"},
+		"queen": {"qu", "een"},
+		"yes":   {"y", "es"},
+		"rhyme": {"rh", "yme"},
+		"apple": {"", "apple"},
+	} {
+		o, r := spoonerism.Split(word)
+		assert.Equal(t, parts, [2]string{o, r}, word)
+	}
+}
+
+func TestPhrase(t *testing.T) {
+	q := spoonerism.Query{Phrase: "crushing blow"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+	assert.Equal(t, "blushing crow", res.Entries[0].Spoonerism)
+
+	q = spoonerism.Query{Phrase: "bad salad"}
+	_, err = q.Run()
+	require.Error(t, err)
+}
+
+func TestSearch(t *testing.T) {
+	q := spoonerism.Query{Enumeration: "8,4"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 2)
+	assert.Equal(t, "blushing crow", res.Entries[0].Phrase)
+	assert.Equal(t, "crushing blow", res.Entries[1].Phrase)
+
+	q = spoonerism.Query{Enumeration: "8,4", Frame: "c....... ...."}
+	res, err = q.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+	assert.Equal(t, "blushing crow", res.Entries[0].Spoonerism)
+}
+
+func TestSearchRemote(t *testing.T) {
+	wordlist.SetDefault(wordlist.Remote{})
+	defer wordlist.SetDefault(words)
+	q := spoonerism.Query{Enumeration: "8,4"}
+	_, err := q.Run()
+	assert.Equal(t, errcode.NotConfigured, errcode.Of(err))
+}
@@ -7,9 +7,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/gotwarlost/crossies/internal/errcode"
 	"github.com/gotwarlost/crossies/internal/htmlplus"
-	"github.com/pkg/errors"
+	"github.com/gotwarlost/crossies/internal/inputerror"
 )
 
 const baseURL = "https://wordhippo.com"
@@ -40,7 +42,7 @@ type Query struct {
 func NewQueryFromParams(values url.Values) (q Query, _ error) {
 	q.Word = values.Get("word")
 	if q.Word == "" {
-		return q, fmt.Errorf("no word specified")
+		return q, inputerror.New("no word specified")
 	}
 
 	q.StartsWith = values.Get("startsWith")
@@ -56,13 +58,13 @@ func NewQueryFromParams(values url.Values) (q Query, _ error) {
 	if minStr != "" {
 		q.MinLetters, err = strconv.Atoi(minStr)
 		if err != nil {
-			return q, errors.Wrapf(err, "invalid min letters %q", minStr)
+			return q, inputerror.New(fmt.Sprintf("invalid min letters %q", minStr))
 		}
 	}
 	if maxStr != "" {
 		q.MaxLetters, err = strconv.Atoi(maxStr)
 		if err != nil {
-			return q, errors.Wrapf(err, "invalid max letters %q", maxStr)
+			return q, inputerror.New(fmt.Sprintf("invalid max letters %q", maxStr))
 		}
 	}
 	return q, nil
@@ -81,7 +83,7 @@ func (q *Query) initialize() error {
 		var err error
 		q.pat, err = regexp.Compile(q.Pattern)
 		if err != nil {
-			return errors.Wrapf(err, "bad regex %q", q.Pattern)
+			return errcode.Wrap(err, errcode.InvalidInput, fmt.Sprintf("bad regex %q", q.Pattern))
 		}
 	}
 	if q.MinLetters > 0 && q.MaxLetters > 0 && q.MinLetters > q.MaxLetters {
@@ -144,7 +146,7 @@ func (q *Query) Run() (*Result, error) {
 		return nil, err
 	}
 	if q.Word == "" {
-		return nil, fmt.Errorf("no word specified")
+		return nil, inputerror.New("no word specified")
 	}
 
 	u := fmt.Sprintf("%s/what-is/another-word-for/%s.html", baseURL, url.PathEscape(q.Word))
@@ -175,7 +177,7 @@ func (q *Query) Run() (*Result, error) {
 		}
 	}
 	if len(uniq) == 0 {
-		return nil, fmt.Errorf("no synonyms for word %q that match the supplied filters", q.Word)
+		return nil, errcode.NoResults("no synonyms for word %q that match the supplied filters", q.Word)
 	}
 	entries := make([]*Entry, 0, len(uniq))
 	for _, e := range uniq {
@@ -184,3 +186,37 @@ func (q *Query) Run() (*Result, error) {
 	q.sortEntries(entries)
 	return &Result{Query: q, Entries: entries}, nil
 }
+
+// Source returns all synonyms of a word or phrase that have at most maxLetters letters, or any number
+// of letters when maxLetters is 0, best first.
+type Source func(word string, maxLetters int) ([]*Entry, error)
+
+// Lookup is a Source that searches wordhippo.
+func Lookup(word string, maxLetters int) ([]*Entry, error) {
+	q := Query{Word: word, MaxLetters: maxLetters, All: true}
+	res, err := q.Run()
+	if err != nil {
+		return nil, err
+	}
+	return res.Entries, nil
+}
+
+var (
+	defaultLock   sync.RWMutex
+	defaultSource Source = Lookup
+)
+
+// Default returns the source of synonyms used by the other tools, which searches wordhippo unless another
+// source has been set.
+func Default() Source {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultSource
+}
+
+// SetDefault sets the source of synonyms used by the other tools.
+func SetDefault(s Source) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultSource = s
+}
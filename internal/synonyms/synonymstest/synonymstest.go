@@ -0,0 +1,26 @@
+// Package synonymstest provides synonym sources for testing the tools that use synonyms.
+package synonymstest
+
+import (
+	"strings"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+)
+
+// Static returns a source that finds synonyms in the supplied map from lower case words to their synonyms,
+// best first, instead of searching online.
+func Static(syns map[string][]string) synonyms.Source {
+	return func(word string, maxLetters int) ([]*synonyms.Entry, error) {
+		var entries []*synonyms.Entry
+		for i, s := range syns[strings.ToLower(word)] {
+			if maxLetters == 0 || len(s) <= maxLetters {
+				entries = append(entries, &synonyms.Entry{Synonym: s, Priority: i + 1})
+			}
+		}
+		if len(entries) == 0 {
+			return nil, errcode.NoResults("no synonyms for word %q", word)
+		}
+		return entries, nil
+	}
+}
@@ -0,0 +1,188 @@
+package wordlist
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/findwords"
+	"github.com/pkg/errors"
+)
+
+const remoteConcurrency = 8
+
+// DefaultScore is the score of words loaded without one.
+const DefaultScore = 50
+
+// Source checks whether candidate strings are real words.
+type Source interface {
+	// Words returns the subset of the supplied candidates that are real words. Candidates
+	// are expected to consist of lower case letters.
+	Words(candidates []string) (map[string]bool, error)
+}
+
+// List is an in-memory word list in which every word has a score, higher scores being better words.
+type List struct {
+	words    map[string]bool
+	scores   map[string]int
+	byLength map[int][]string
+}
+
+// New returns a list containing the supplied words with the default score.
+func New(words []string) *List {
+	l := &List{words: map[string]bool{}, scores: map[string]int{}, byLength: map[int][]string{}}
+	for _, w := range words {
+		l.add(w, DefaultScore)
+	}
+	return l
+}
+
+func (l *List) add(word string, score int) {
+	w := clue.Letters(word)
+	if w == "" {
+		return
+	}
+	if l.words[w] {
+		if score > l.scores[w] {
+			l.scores[w] = score
+		}
+		return
+	}
+	l.words[w] = true
+	l.scores[w] = score
+	l.byLength[len(w)] = append(l.byLength[len(w)], w)
+}
+
+// Load loads a word list with one word per line. A word may be followed by a tab or semicolon and a
+// numeric score, as in "gazebo;50"; words without a score get the default score. Blank lines and lines
+// starting with # are ignored.
+func Load(r io.Reader) (*List, error) {
+	l := New(nil)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		score := DefaultScore
+		if pos := strings.IndexAny(line, "\t;"); pos >= 0 {
+			if n, err := strconv.Atoi(strings.TrimSpace(line[pos+1:])); err == nil {
+				score = n
+			}
+			line = line[:pos]
+		}
+		l.add(line, score)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read word list")
+	}
+	return l, nil
+}
+
+// LoadFile loads a word list from the supplied file.
+func LoadFile(file string) (*List, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	l, err := Load(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "load %s", file)
+	}
+	return l, nil
+}
+
+// Contains returns true if the list contains the supplied word.
+func (l *List) Contains(word string) bool {
+	return l.words[clue.Letters(word)]
+}
+
+// WithLength returns the words in the list with the supplied number of letters.
+func (l *List) WithLength(n int) []string {
+	return l.byLength[n]
+}
+
+// Score returns the score of a word, 0 if it is not in the list.
+func (l *List) Score(word string) int {
+	return l.scores[clue.Letters(word)]
+}
+
+// Len returns the number of words in the list.
+func (l *List) Len() int {
+	return len(l.words)
+}
+
+// Words implements Source.
+func (l *List) Words(candidates []string) (map[string]bool, error) {
+	ret := map[string]bool{}
+	for _, c := range candidates {
+		if l.words[c] {
+			ret[c] = true
+		}
+	}
+	return ret, nil
+}
+
+// Remote is a source that looks words up using the find-words machinery.
+type Remote struct{}
+
+// Words implements Source.
+func (Remote) Words(candidates []string) (map[string]bool, error) {
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	ret := map[string]bool{}
+	var finalErr error
+	sem := make(chan struct{}, remoteConcurrency)
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		wg.Add(1)
+		go func(word string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ok, err := findwords.IsWord(word)
+			l.Lock()
+			defer l.Unlock()
+			if err != nil {
+				finalErr = err
+				return
+			}
+			if ok {
+				ret[word] = true
+			}
+		}(c)
+	}
+	wg.Wait()
+	if finalErr != nil {
+		return nil, finalErr
+	}
+	return ret, nil
+}
+
+var (
+	defaultLock   sync.RWMutex
+	defaultSource Source = Remote{}
+)
+
+// Default returns the default word source, which looks words up remotely unless a local list has been set.
+func Default() Source {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultSource
+}
+
+// SetDefault sets the default word source.
+func SetDefault(s Source) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultSource = s
+}
@@ -0,0 +1,46 @@
+package wordlist_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/wordlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testList = `# test words
+gazebo;60
+Ice cream	70
+cat
+cat;20
+dog;not a score
+
+`
+
+func TestLoad(t *testing.T) {
+	l, err := wordlist.Load(strings.NewReader(testList))
+	require.NoError(t, err)
+	assert.Equal(t, 4, l.Len())
+
+	tests := []struct {
+		word  string
+		score int
+	}{
+		{"gazebo", 60},
+		{"icecream", 70},
+		{"ICE CREAM", 70},
+		{"cat", wordlist.DefaultScore}, // the best score of a repeated word is kept
+		{"dog", wordlist.DefaultScore},
+		{"emu", 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.score, l.Score(test.word), test.word)
+		assert.Equal(t, test.score > 0, l.Contains(test.word), test.word)
+	}
+	assert.ElementsMatch(t, []string{"cat", "dog"}, l.WithLength(3))
+
+	found, err := l.Words([]string{"cat", "emu", "gazebo"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"cat": true, "gazebo": true}, found)
+}
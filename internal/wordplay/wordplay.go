@@ -0,0 +1,275 @@
+package wordplay
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gotwarlost/crossies/internal/charade"
+	"github.com/gotwarlost/crossies/internal/clue"
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/inputerror"
+)
+
+const maxResults = 500
+
+// Type is the kind of construction.
+type Type string
+
+// available construction types
+const (
+	TypeContainer Type = "container"
+	TypeReversal  Type = "reversal"
+)
+
+// Query is a query to solve container/contents and reversal wordplay. Components for each side come
+// from the synonyms and abbreviations of a clue fragment and from explicitly supplied words. When only
+// one side is supplied, reversals of its components are returned.
+type Query struct {
+	Left        string   `json:"left,omitempty"`        // first clue fragment
+	Right       string   `json:"right,omitempty"`       // second clue fragment
+	LeftWords   []string `json:"leftWords,omitempty"`   // additional components for the first fragment
+	RightWords  []string `json:"rightWords,omitempty"`  // additional components for the second fragment
+	Enumeration string   `json:"enumeration,omitempty"` // enumeration of the answer
+	Frame       string   `json:"frame,omitempty"`       // known letters of the answer with '.' for unknowns
+	pattern     *clue.Pattern
+}
+
+func hasSide(fragment string, words []string) bool {
+	return strings.TrimSpace(fragment) != "" || len(words) > 0
+}
+
+func (q *Query) initialize() error {
+	if !hasSide(q.Left, q.LeftWords) && !hasSide(q.Right, q.RightWords) {
+		return inputerror.New("no fragments or words specified")
+	}
+	var err error
+	q.pattern, err = clue.NewPattern(q.Enumeration, q.Frame)
+	if err != nil {
+		return err
+	}
+	if q.pattern.Length() == 0 {
+		return inputerror.New("no enumeration or frame specified")
+	}
+	return nil
+}
+
+// NewQueryFromParams returns a query object from URL parameters
+func NewQueryFromParams(values url.Values) (q Query, _ error) {
+	q.Left = values.Get("left")
+	q.Right = values.Get("right")
+	q.LeftWords = values["leftWord"]
+	q.RightWords = values["rightWord"]
+	q.Enumeration = values.Get("enumeration")
+	q.Frame = values.Get("frame")
+	if err := q.initialize(); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// Construction is an answer built from the components.
+type Construction struct {
+	Answer        string             `json:"answer"`
+	Type          Type               `json:"type"`
+	Derivation    string             `json:"derivation"`              // for example P(ASS)ION or P(SSA<)ION
+	Outer         *charade.Component `json:"outer"`                   // the container, or the reversed component
+	Inner         *charade.Component `json:"inner,omitempty"`         // the contents
+	OuterReversed bool               `json:"outerReversed,omitempty"` // whether the container is reversed
+	InnerReversed bool               `json:"innerReversed,omitempty"` // whether the contents are reversed
+}
+
+// Result is the result of a query
+type Result struct {
+	Query         *Query          `json:"query,omitempty"`
+	Constructions []*Construction `json:"constructions"`
+}
+
+// Reverse returns the supplied string backwards.
+func Reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func (q *Query) components(fragment string, words []string) ([]*charade.Component, error) {
+	var ret []*charade.Component
+	seen := map[string]bool{}
+	for _, w := range words {
+		text := clue.Letters(w)
+		if text == "" || seen[text] || len(text) > q.pattern.Length() {
+			continue
+		}
+		seen[text] = true
+		ret = append(ret, &charade.Component{Part: w, Text: text, Source: charade.SourceLiteral})
+	}
+	if strings.TrimSpace(fragment) == "" {
+		return ret, nil
+	}
+	comps, err := charade.Components(fragment, q.pattern.Length())
+	if err != nil {
+		// the supplied words can still be used when the fragment has no components, but other failures
+		// are reported
+		if errcode.IsNoResults(err) && len(ret) > 0 {
+			return ret, nil
+		}
+		return nil, err
+	}
+	for _, c := range comps {
+		if !seen[c.Text] {
+			seen[c.Text] = true
+			ret = append(ret, c)
+		}
+	}
+	return ret, nil
+}
+
+type builder struct {
+	q       *Query
+	seen    map[string]bool
+	results []*Construction
+}
+
+func (b *builder) add(c *Construction) {
+	if len(b.results) >= maxResults || !b.q.pattern.Match(c.Answer) {
+		return
+	}
+	key := c.Answer + "|" + c.Derivation
+	if b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	b.results = append(b.results, c)
+}
+
+func (b *builder) reversals(comps []*charade.Component) {
+	for _, c := range comps {
+		if len(c.Text) != b.q.pattern.Length() {
+			continue
+		}
+		b.add(&Construction{
+			Answer:        Reverse(c.Text),
+			Type:          TypeReversal,
+			Derivation:    strings.ToUpper(c.Text) + "<",
+			Outer:         c,
+			OuterReversed: true,
+		})
+	}
+}
+
+func (b *builder) containers(outers, inners []*charade.Component) {
+	n := b.q.pattern.Length()
+	for _, o := range outers {
+		for _, in := range inners {
+			if len(o.Text)+len(in.Text) != n || len(o.Text) < 2 {
+				continue
+			}
+			for _, outRev := range []bool{false, true} {
+				for _, inRev := range []bool{false, true} {
+					outText, inText := o.Text, in.Text
+					if outRev {
+						outText = Reverse(outText)
+					}
+					if inRev {
+						inText = Reverse(inText)
+					}
+					if (outRev && outText == o.Text) || (inRev && inText == in.Text) {
+						continue // palindromes add nothing
+					}
+					for pos := 1; pos < len(outText); pos++ {
+						b.add(&Construction{
+							Answer:        outText[:pos] + inText + outText[pos:],
+							Type:          TypeContainer,
+							Derivation:    derivation(outText, inText, pos, o.Text, in.Text, outRev, inRev),
+							Outer:         o,
+							Inner:         in,
+							OuterReversed: outRev,
+							InnerReversed: inRev,
+						})
+					}
+				}
+			}
+		}
+	}
+}
+
+// derivation returns a readable form of a container, with the contents in parentheses. Reversed
+// contents are written as the original component followed by <, and a reversed container is noted
+// at the end.
+func derivation(outText, inText string, pos int, outOrig, inOrig string, outRev, inRev bool) string {
+	inner := strings.ToUpper(inText)
+	if inRev {
+		inner = strings.ToUpper(inOrig) + "<"
+	}
+	ret := strings.ToUpper(outText[:pos]) + "(" + inner + ")" + strings.ToUpper(outText[pos:])
+	if outRev {
+		ret += ", " + strings.ToUpper(outText) + " = " + strings.ToUpper(outOrig) + "<"
+	}
+	return ret
+}
+
+// score prefers constructions with fewer reversals that use synonyms or abbreviations.
+func (c *Construction) score() int {
+	s := 0
+	if c.OuterReversed {
+		s--
+	}
+	if c.InnerReversed {
+		s--
+	}
+	for _, comp := range []*charade.Component{c.Outer, c.Inner} {
+		if comp != nil && comp.Source != charade.SourceLiteral {
+			s++
+		}
+	}
+	return s
+}
+
+// Run finds components for both sides concurrently and returns the containers and reversals that fit.
+func (q *Query) Run() (*Result, error) {
+	if err := q.initialize(); err != nil {
+		return nil, err
+	}
+	var left, right []*charade.Component
+	var leftErr, rightErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if hasSide(q.Left, q.LeftWords) {
+			left, leftErr = q.components(q.Left, q.LeftWords)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if hasSide(q.Right, q.RightWords) {
+			right, rightErr = q.components(q.Right, q.RightWords)
+		}
+	}()
+	wg.Wait()
+	if leftErr != nil {
+		return nil, leftErr
+	}
+	if rightErr != nil {
+		return nil, rightErr
+	}
+
+	b := &builder{q: q, seen: map[string]bool{}}
+	if left == nil || right == nil {
+		b.reversals(left)
+		b.reversals(right)
+	} else {
+		b.containers(left, right)
+		b.containers(right, left)
+	}
+	if len(b.results) == 0 {
+		return nil, errcode.NoResults("no constructions of length %d found", q.pattern.Length())
+	}
+	sort.SliceStable(b.results, func(i, j int) bool {
+		return b.results[i].score() > b.results[j].score()
+	})
+	return &Result{Query: q, Constructions: b.results}, nil
+}
@@ -0,0 +1,56 @@
+package wordplay_test
+
+import (
+	"testing"
+
+	"github.com/gotwarlost/crossies/internal/errcode"
+	"github.com/gotwarlost/crossies/internal/synonyms"
+	"github.com/gotwarlost/crossies/internal/wordplay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer(t *testing.T) {
+	q := wordplay.Query{LeftWords: []string{"ass"}, RightWords: []string{"pion"}, Frame: "pa....n"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Constructions, 1)
+	c := res.Constructions[0]
+	assert.Equal(t, "passion", c.Answer)
+	assert.Equal(t, "P(ASS)ION", c.Derivation)
+	assert.Equal(t, wordplay.TypeContainer, c.Type)
+}
+
+func TestReversedContents(t *testing.T) {
+	q := wordplay.Query{LeftWords: []string{"ssa"}, RightWords: []string{"pion"}, Frame: "pa....n"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Constructions, 1)
+	assert.Equal(t, "P(SSA<)ION", res.Constructions[0].Derivation)
+	assert.True(t, res.Constructions[0].InnerReversed)
+}
+
+func TestReversal(t *testing.T) {
+	q := wordplay.Query{LeftWords: []string{"live", "stop"}, Enumeration: "4"}
+	res, err := q.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Constructions, 2)
+	assert.Equal(t, "evil", res.Constructions[0].Answer)
+	assert.Equal(t, "LIVE<", res.Constructions[0].Derivation)
+
+	q = wordplay.Query{LeftWords: []string{"live"}, Frame: "x..."}
+	_, err = q.Run()
+	require.Error(t, err)
+	assert.Equal(t, errcode.NotFound, errcode.Of(err))
+}
+
+func TestComponentErrors(t *testing.T) {
+	defer synonyms.SetDefault(synonyms.Default())
+	synonyms.SetDefault(func(string, int) ([]*synonyms.Entry, error) {
+		return nil, errcode.New(errcode.UpstreamUnavailable, "down")
+	})
+	// failures looking up the fragment are not hidden by the supplied words
+	q := wordplay.Query{Left: "donkey", LeftWords: []string{"ass"}, RightWords: []string{"pion"}, Frame: "pa....n"}
+	_, err := q.Run()
+	assert.Equal(t, errcode.UpstreamUnavailable, errcode.Of(err))
+}